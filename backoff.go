@@ -0,0 +1,136 @@
+package crawly
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+//////////////////////////////////////////////////
+
+type BackoffPolicy interface {
+	Backoff(attempt int) time.Duration
+}
+
+type BackoffFunc func(attempt int) time.Duration
+
+func (f BackoffFunc) Backoff(attempt int) time.Duration {
+	if f == nil {
+		return 0
+	}
+
+	return f(attempt)
+}
+
+//////////////////////////////////////////////////
+
+type ConstantBackoff struct {
+	Delay time.Duration `json:"delay"`
+}
+
+func (b ConstantBackoff) Backoff(attempt int) time.Duration {
+	return b.Delay
+}
+
+//////////////////////////////////////////////////
+
+type ExponentialBackoff struct {
+	Initial    time.Duration `json:"initial"`
+	Maximum    time.Duration `json:"maximum"`
+	Multiplier float64       `json:"multiplier"`
+	Jitter     float64       `json:"jitter"`
+}
+
+var DefaultExponentialBackoffMultiplier = 2.0
+
+func (b ExponentialBackoff) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = DefaultExponentialBackoffMultiplier
+	}
+
+	d := float64(b.Initial) * math.Pow(multiplier, float64(attempt-1))
+	if b.Maximum > 0 && d > float64(b.Maximum) {
+		d = float64(b.Maximum)
+	}
+
+	if b.Jitter > 0 {
+		jitter := b.Jitter
+		if jitter > 1 {
+			jitter = 1
+		}
+
+		d -= d * jitter * rand.Float64()
+	}
+
+	return clampBackoff(d, b.Maximum)
+}
+
+//////////////////////////////////////////////////
+
+type DecorrelatedJitterBackoff struct {
+	Base    time.Duration `json:"base"`
+	Maximum time.Duration `json:"maximum"`
+}
+
+func (b DecorrelatedJitterBackoff) Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	base := float64(b.Base)
+	d := base
+
+	// NOTE: the policy is stateless, so the "previous" delays are
+	// re-sampled on every call (which yields the same distribution).
+	for i := 1; i < attempt; i++ {
+		d = base + rand.Float64()*(d*3-base)
+
+		if b.Maximum > 0 && d >= float64(b.Maximum) {
+			d = float64(b.Maximum)
+			break
+		}
+	}
+
+	return clampBackoff(d, b.Maximum)
+}
+
+//////////////////////////////////////////////////
+
+func clampBackoff(d float64, maximum time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+
+	if d >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+
+	if maximum > 0 && time.Duration(d) > maximum {
+		return maximum
+	}
+
+	return time.Duration(d)
+}
+
+func retryDelay(policy BackoffPolicy, attempt int, minimumDelay time.Duration) time.Duration {
+	if policy == nil || attempt < 1 {
+		return minimumDelay
+	}
+
+	// NOTE: the minimum delay is a floor, so that a policy without a delay
+	// (e.g., ConstantBackoff{}) cannot make retries back-to-back.
+	d := policy.Backoff(attempt)
+	if d < minimumDelay {
+		d = minimumDelay
+	}
+	if d < 0 {
+		d = 0
+	}
+
+	return d
+}
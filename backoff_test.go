@@ -0,0 +1,81 @@
+package crawly
+
+import (
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////
+
+func TestConstantBackoff(t *testing.T) {
+	b := ConstantBackoff{Delay: time.Second}
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		if d := b.Backoff(attempt); d != time.Second {
+			t.Fatalf("attempt %d: expected %v, got %v", attempt, time.Second, d)
+		}
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := ExponentialBackoff{Initial: time.Second, Maximum: 10 * time.Second}
+
+	for attempt, expected := range map[int]time.Duration{
+		1: time.Second,
+		2: 2 * time.Second,
+		4: 8 * time.Second,
+		5: 10 * time.Second,
+		9: 10 * time.Second,
+	} {
+		if d := b.Backoff(attempt); d != expected {
+			t.Errorf("attempt %d: expected %v, got %v", attempt, expected, d)
+		}
+	}
+
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := b.Backoff(3); d < 2*time.Second || d > 4*time.Second {
+			t.Fatalf("jittered delay out of bounds: %v", d)
+		}
+	}
+}
+
+func TestDecorrelatedJitterBackoff(t *testing.T) {
+	b := DecorrelatedJitterBackoff{Base: time.Second, Maximum: 10 * time.Second}
+
+	if d := b.Backoff(1); d != time.Second {
+		t.Fatalf("expected the first delay to be the base, got %v", d)
+	}
+
+	for i := 0; i < 100; i++ {
+		if d := b.Backoff(5); d < b.Base || d > b.Maximum {
+			t.Fatalf("delay out of bounds: %v", d)
+		}
+	}
+}
+
+func TestRetryDelayFlooredAtMinimum(t *testing.T) {
+	minimum := 500 * time.Millisecond
+
+	for name, policy := range map[string]BackoffPolicy{
+		"nil":          nil,
+		"func":         BackoffFunc(nil),
+		"constant":     ConstantBackoff{},
+		"exponential":  ExponentialBackoff{},
+		"decorrelated": DecorrelatedJitterBackoff{},
+		"negative":     ConstantBackoff{Delay: -time.Second},
+	} {
+		if d := retryDelay(policy, 1, minimum); d != minimum {
+			t.Errorf("%s: expected the minimum delay, got %v", name, d)
+		}
+	}
+
+	if d := retryDelay(ConstantBackoff{Delay: time.Second}, 1, minimum); d != time.Second {
+		t.Errorf("expected the policy delay above the minimum, got %v", d)
+	}
+
+	s := attemptSettlement{retryAfter: time.Millisecond}
+	if d := s.delay(nil, minimum); d != minimum {
+		t.Errorf("expected retry-after to be floored, got %v", d)
+	}
+}
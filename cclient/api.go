@@ -9,7 +9,9 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	fhttp "github.com/bogdanfinn/fhttp"
 
@@ -274,6 +276,60 @@ func (e *APIError) Unwrap() error {
 	return e.err
 }
 
+func (e *APIError) Permanent() bool {
+	switch e.Code {
+	case http.StatusNotFound, http.StatusGone:
+		return true
+	}
+
+	return false
+}
+
+func (e *APIError) Temporary() bool {
+	switch e.Code {
+	case http.StatusRequestTimeout,
+		http.StatusTooEarly,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+func (e *APIError) RetryAfter() (delay time.Duration, ok bool) {
+	if e.Code != http.StatusTooManyRequests && e.Code != http.StatusServiceUnavailable {
+		return
+	}
+
+	v := strings.TrimSpace(e.Header.Get("Retry-After"))
+	if v == "" {
+		return
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return
+		}
+
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		delay = time.Until(t)
+		if delay < 0 {
+			delay = 0
+		}
+
+		return delay, true
+	}
+
+	return
+}
+
 func checkAPIResponse(res *fhttp.Response) error {
 	if res.StatusCode >= 200 && res.StatusCode <= 299 {
 		return nil
@@ -0,0 +1,33 @@
+package crawly
+
+//////////////////////////////////////////////////
+
+type DataChange struct {
+	Previous any `json:"previous"`
+	Current  any `json:"current"`
+}
+
+type ChangeDetector func(handle Handle, previous any, current any) (changed bool)
+
+type DataEqualer interface {
+	Equal(data any) bool
+}
+
+//////////////////////////////////////////////////
+
+func detectChange(detector ChangeDetector, handle Handle, previous any, current any) (changed bool) {
+	// NOTE: the very first observation of an entity is not a "change".
+	if previous == nil {
+		return
+	}
+
+	if detector != nil {
+		return detector(handle, previous, current)
+	}
+
+	if eq, ok := previous.(DataEqualer); ok {
+		return !eq.Equal(current)
+	}
+
+	return
+}
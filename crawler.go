@@ -3,7 +3,11 @@ package crawly
 import (
 	"context"
 	"errors"
+	"hash/fnv"
 	"log/slog"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rubpy/crawly/clog"
@@ -17,11 +21,24 @@ type AnyCrawler interface {
 	SetLogger(logger *slog.Logger)
 	Log(ctx context.Context, params clog.Params)
 
-	Tracked() (handles []Handle)
+	Tracked(tags ...string) (handles []Handle)
 	IsTracked(handle Handle) bool
-	Track(ctx context.Context, handle Handle) (tracked bool, err error)
+	Track(ctx context.Context, handle Handle, opts ...TrackOption) (tracked bool, err error)
+	TrackAndWait(ctx context.Context, handle Handle, opts ...TrackOption) (result TrackingResult, err error)
 	Untrack(ctx context.Context, handle Handle) (tracked bool, err error)
 	UntrackAll(ctx context.Context) (untracked int, err error)
+	ScheduleRefresh(ctx context.Context, handle Handle) (tracked bool, err error)
+	Suspended() (entities []Entity)
+	Suspend(ctx context.Context, handle Handle) (tracked bool, err error)
+	SuspendUntil(ctx context.Context, handle Handle, until time.Time) (tracked bool, err error)
+	Unsuspend(ctx context.Context, handle Handle) (tracked bool, err error)
+	Update(ctx context.Context, handle Handle, data any) (tracked bool, err error)
+	TrackMany(ctx context.Context, handles []Handle, opts ...TrackOption) (outcomes []OrderOutcome, err error)
+	UntrackMany(ctx context.Context, handles []Handle) (outcomes []OrderOutcome, err error)
+	UntrackWhere(ctx context.Context, predicate func(entity Entity) bool) (outcomes []OrderOutcome, err error)
+	Pending() (orders []Order)
+	Touch(ctx context.Context, handle Handle, ttl time.Duration) (ok bool, err error)
+	Refresh(ctx context.Context, handle Handle) (result TrackingResult, err error)
 
 	Paused() bool
 	Pause(ctx context.Context)
@@ -29,25 +46,44 @@ type AnyCrawler interface {
 	Immediate(ctx context.Context, in time.Duration) (ok bool, err error)
 
 	Active() bool
+	State() csync.SessionState
 	Start(ctx context.Context, sessionSettings SessionSettings) error
 	Stop(ctx context.Context) (ok bool, err error)
 	Listen() csync.Listener[*Result]
 }
 
 type Crawler struct {
-	logger   *slog.Logger
-	settings csync.Value[CrawlerSettings]
-	session  csync.Session[*Result]
-
-	orders   csync.Map[Handle, Order]
-	entities csync.Map[Handle, Entity]
-
-	handlers csync.Value[CrawlerHandlers]
+	logger       *slog.Logger
+	settings     csync.Value[CrawlerSettings]
+	kindSettings csync.Map[string, SettingsOverride]
+	session      csync.Session[*Result]
+
+	orders        csync.Map[Handle, Order]
+	orderLock     sync.Mutex
+	orderSequence atomic.Uint64
+	entities      csync.Map[Handle, Entity]
+	entityLocks   [entityLockStripes]sync.Mutex
+	schedule      scheduler
+	busy          csync.Map[Handle, struct{}]
+	entitySlots   csync.Semaphore
+
+	store     csync.Value[crawlerStore]
+	restored  atomic.Bool
+	startLock sync.Mutex
+
+	handlers       csync.Value[CrawlerHandlers]
+	middlewares    csync.Value[[]Middleware]
+	middlewareLock sync.Mutex
+
+	events     csync.Broadcaster[Event]
+	eventsOnce sync.Once
 }
 
 type CrawlerHandlers struct {
 	Order  OrderHandler
 	Entity EntityHandler
+
+	ChangeDetector ChangeDetector
 }
 
 //////////////////////////////////////////////////
@@ -72,6 +108,10 @@ func (cr *Crawler) Active() bool {
 	return cr.session.Active()
 }
 
+func (cr *Crawler) State() csync.SessionState {
+	return cr.session.State()
+}
+
 func (cr *Crawler) Paused() bool {
 	return cr.session.Paused()
 }
@@ -89,11 +129,34 @@ func (cr *Crawler) Immediate(ctx context.Context, in time.Duration) (ok bool, er
 }
 
 func (cr *Crawler) Start(ctx context.Context, sessionSettings SessionSettings) error {
+	cr.startLock.Lock()
+	defer cr.startLock.Unlock()
+
+	if cr.session.Active() {
+		return csync.SessionAlreadyActive
+	}
+
+	// NOTE: the store is only restored from once (rather than on every
+	// start), so that orders and entities that live on between sessions are
+	// not replaced by their persisted copies.
+	if !cr.restored.Load() {
+		if err := cr.restore(ctx); err != nil {
+			return err
+		}
+
+		cr.restored.Store(true)
+	}
+
 	return cr.session.Start(ctx, cr.sessionHandler, csync.SessionSettings(sessionSettings))
 }
 
 func (cr *Crawler) Stop(ctx context.Context) (ok bool, err error) {
-	return cr.session.Stop(ctx)
+	ok, err = cr.session.Stop(ctx)
+	if ok {
+		cr.schedule.Sleep()
+	}
+
+	return
 }
 
 func (cr *Crawler) Listen() csync.Listener[*Result] {
@@ -141,42 +204,159 @@ func (cr *Crawler) sessionHandler(ctx context.Context, sess *csync.Session[*Resu
 		Entities: make(map[Handle]TrackingResult),
 	}
 	defer func() {
+		result.Idle = result.Err == nil && cr.idle()
 		result.Timestamp = time.Now()
+
+		cr.wake(sess)
 	}()
 
+	var resultLock sync.Mutex
+	settings := cr.loadSettings()
+
 	if result.Err == nil {
-		cr.orders.Range(func(handle Handle, order Order) bool {
-			result.Idle = false
+		orders := cr.Pending()
+		sort.SliceStable(orders, func(i, j int) bool {
+			return orders[i].Priority > orders[j].Priority
+		})
 
+		result.Err = processConcurrently(ctx, settings.OrderConcurrency, orders, func(ctx context.Context, order Order) error {
 			var tr TrackingResult
-			if err := cr.processOrder(ctx, &order, &tr); err != nil {
-				result.Err = err
-				return false
+			if processed, err := cr.processOrderExclusively(ctx, &order, &tr); err != nil || !processed {
+				return err
 			}
 
-			cr.commitTrackingResult(&tr)
-			result.Orders[handle] = tr
+			resultLock.Lock()
+			result.Orders[order.Handle] = tr
+			resultLock.Unlock()
 
-			return true
+			return nil
 		})
 	}
 
 	if result.Err == nil {
-		cr.entities.Range(func(handle Handle, entity Entity) bool {
-			result.Idle = false
+		now := time.Now()
 
+		entities, expired := cr.sweepExpired(ctx, cr.schedule.Due(now), now)
+		for handle, tr := range expired {
+			result.Entities[handle] = tr
+		}
+
+		result.Err = processConcurrently(ctx, settings.EntityConcurrency, entities, func(ctx context.Context, entity Entity) error {
 			var tr TrackingResult
-			if err := cr.processEntity(ctx, &entity, &tr); err != nil {
-				result.Err = err
-				return false
+			if err := cr.processExclusively(ctx, entity.Handle, false, &tr); err != nil {
+				if err == BusyHandle || err == UntrackedHandle {
+					return nil
+				}
+
+				return err
 			}
 
-			cr.commitTrackingResult(&tr)
-			result.Entities[handle] = tr
+			resultLock.Lock()
+			result.Entities[entity.Handle] = tr
+			resultLock.Unlock()
 
-			return true
+			return nil
 		})
 	}
 
 	return
 }
+
+// NOTE: entity commits are serialized per handle (or rather, per stripe of
+// handles), so that committing (and persisting) one entity does not hold up
+// the rest.
+const entityLockStripes = 64
+
+func (cr *Crawler) entityLock(handle Handle) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(handle.String()))
+
+	return &cr.entityLocks[h.Sum32()%entityLockStripes]
+}
+
+func (cr *Crawler) idle() bool {
+	idle := true
+
+	cr.orders.Range(func(_ Handle, _ Order) bool {
+		idle = false
+		return false
+	})
+	if !idle {
+		return false
+	}
+
+	cr.entities.Range(func(_ Handle, _ Entity) bool {
+		idle = false
+		return false
+	})
+
+	return idle
+}
+
+func processConcurrently[T any](ctx context.Context, concurrency int, items []T, process func(ctx context.Context, item T) error) (err error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	if concurrency == 1 || len(items) < 2 {
+		for _, item := range items {
+			if err = process(ctx, item); err != nil {
+				return
+			}
+		}
+
+		return
+	}
+
+	var wg sync.WaitGroup
+	var errLock sync.Mutex
+
+	failed := func() bool {
+		errLock.Lock()
+		defer errLock.Unlock()
+
+		return err != nil
+	}
+
+	slots := make(chan struct{}, concurrency)
+
+itemLoop:
+	for _, item := range items {
+		select {
+		case slots <- struct{}{}:
+
+		case <-ctx.Done():
+			errLock.Lock()
+			if err == nil {
+				err = ctx.Err()
+			}
+			errLock.Unlock()
+
+			break itemLoop
+		}
+
+		if failed() {
+			<-slots
+			break itemLoop
+		}
+
+		wg.Add(1)
+		go func(item T) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			if processErr := process(ctx, item); processErr != nil {
+				errLock.Lock()
+				if err == nil {
+					err = processErr
+				}
+				errLock.Unlock()
+			}
+		}(item)
+	}
+
+	wg.Wait()
+	return
+}
@@ -0,0 +1,316 @@
+package crawly
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rubpy/crawly/csync"
+)
+
+//////////////////////////////////////////////////
+
+func TestMain(m *testing.M) {
+	csync.MinimumSessionInterval = 20 * time.Millisecond
+	MinimumWakeDelay = 10 * time.Millisecond
+
+	os.Exit(m.Run())
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func newTestCrawler(t *testing.T) *Crawler {
+	t.Helper()
+	registerTestHandle(t)
+
+	cr := &Crawler{}
+	SetCrawlerHandlers(cr, CrawlerHandlers{
+		Order: func(ctx context.Context, order *Order, result *TrackingResult) error {
+			return nil
+		},
+		Entity: func(ctx context.Context, entity *Entity, result *TrackingResult) error {
+			return nil
+		},
+	})
+
+	return cr
+}
+
+func startTestCrawler(t *testing.T, cr *Crawler, settings SessionSettings) {
+	t.Helper()
+
+	if settings.Interval == 0 {
+		settings.Interval = 20 * time.Millisecond
+	}
+
+	if err := cr.Start(context.Background(), settings); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if _, err := cr.Stop(ctx); err != nil {
+			t.Errorf("Stop: %v", err)
+		}
+	})
+}
+
+//////////////////////////////////////////////////
+
+func TestCrawlerPauseIdleCycle(t *testing.T) {
+	cr := newTestCrawler(t)
+	startTestCrawler(t, cr, SessionSettings{PauseIdle: true})
+
+	ctx := context.Background()
+
+	waitFor(t, "the idle crawler to pause", cr.Paused)
+	if !cr.Active() {
+		t.Fatalf("paused crawler is not active")
+	}
+
+	if _, err := cr.Track(ctx, testHandle("a")); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	if cr.Paused() {
+		t.Fatalf("Track did not resume the crawler")
+	}
+
+	waitFor(t, "the entity to be tracked", func() bool {
+		return cr.IsTracked(testHandle("a"))
+	})
+
+	// NOTE: with an entity being tracked, the crawler should keep running.
+	time.Sleep(100 * time.Millisecond)
+	if cr.Paused() {
+		t.Fatalf("crawler paused while tracking an entity")
+	}
+
+	if _, err := cr.Untrack(ctx, testHandle("a")); err != nil {
+		t.Fatalf("Untrack: %v", err)
+	}
+
+	waitFor(t, "the entity to be untracked", func() bool {
+		return !cr.IsTracked(testHandle("a"))
+	})
+	waitFor(t, "the crawler to pause again", cr.Paused)
+
+	if tracked := cr.Tracked(); len(tracked) != 0 {
+		t.Fatalf("expected nothing to be tracked, got %v", tracked)
+	}
+}
+
+func TestCrawlerPauseIdleManualResume(t *testing.T) {
+	cr := newTestCrawler(t)
+	startTestCrawler(t, cr, SessionSettings{PauseIdle: true})
+
+	waitFor(t, "the idle crawler to pause", cr.Paused)
+
+	// NOTE: a manual resume lasts for (at least) one more pass, after which
+	// the still-idle crawler is paused again.
+	listener := cr.Listen()
+	defer listener.Discard()
+
+	cr.Resume(context.Background())
+
+	select {
+	case result := <-listener.Channel():
+		if !result.IsIdle() {
+			t.Fatalf("expected an idle result, got %+v", result)
+		}
+
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for a pass after resuming")
+	}
+
+	waitFor(t, "the crawler to pause again", cr.Paused)
+}
+
+func TestProcessConcurrentlyRespectsLimit(t *testing.T) {
+	var inFlight, peak atomic.Int64
+	var processed atomic.Int64
+
+	items := make([]int, 20)
+	err := processConcurrently(context.Background(), 3, items, func(ctx context.Context, item int) error {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		processed.Add(1)
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("processConcurrently: %v", err)
+	}
+
+	if n := processed.Load(); n != int64(len(items)) {
+		t.Fatalf("expected %d items to be processed, got %d", len(items), n)
+	}
+	if p := peak.Load(); p > 3 || p < 2 {
+		t.Fatalf("expected up to 3 items in flight, got %d", p)
+	}
+}
+
+func TestProcessConcurrentlyStopsOnError(t *testing.T) {
+	boom := errors.New("boom")
+
+	for _, concurrency := range []int{1, 2} {
+		var processed atomic.Int64
+
+		items := make([]int, 50)
+		for i := range items {
+			items[i] = i
+		}
+
+		err := processConcurrently(context.Background(), concurrency, items, func(ctx context.Context, item int) error {
+			processed.Add(1)
+			if item == 0 {
+				return boom
+			}
+
+			time.Sleep(5 * time.Millisecond)
+			return nil
+		})
+		if !errors.Is(err, boom) {
+			t.Fatalf("concurrency %d: expected boom, got %v", concurrency, err)
+		}
+		if n := processed.Load(); n >= int64(len(items)) {
+			t.Fatalf("concurrency %d: dispatch did not stop after an error (%d processed)", concurrency, n)
+		}
+	}
+}
+
+func TestProcessConcurrentlyCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var started atomic.Int64
+	done := make(chan error, 1)
+	go func() {
+		done <- processConcurrently(ctx, 2, make([]int, 10), func(ctx context.Context, item int) error {
+			started.Add(1)
+			<-ctx.Done()
+
+			return nil
+		})
+	}()
+
+	waitFor(t, "the first items to be dispatched", func() bool {
+		return started.Load() == 2
+	})
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("processConcurrently did not return after cancellation")
+	}
+
+	if n := started.Load(); n != 2 {
+		t.Fatalf("items were dispatched after cancellation: %d", n)
+	}
+}
+
+func TestCrawlerOneResultPerPass(t *testing.T) {
+	cr := newTestCrawler(t)
+	SetCrawlerSettings(cr, CrawlerSettings{OrderConcurrency: 4, EntityConcurrency: 4})
+
+	var inFlight, peak atomic.Int64
+	SetCrawlerHandlers(cr, CrawlerHandlers{
+		Order: func(ctx context.Context, order *Order, result *TrackingResult) error {
+			return nil
+		},
+		Entity: func(ctx context.Context, entity *Entity, result *TrackingResult) error {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+
+			time.Sleep(2 * time.Millisecond)
+			return nil
+		},
+	})
+
+	handles := make([]Handle, 12)
+	for i := range handles {
+		handles[i] = testHandle(string(rune('a' + i)))
+	}
+
+	listener := cr.Listen()
+	defer listener.Discard()
+
+	startTestCrawler(t, cr, SessionSettings{Interval: time.Hour})
+
+	if _, err := cr.TrackMany(context.Background(), handles); err != nil {
+		t.Fatalf("TrackMany: %v", err)
+	}
+
+	var passes []uint64
+	for {
+		if _, err := cr.Immediate(context.Background(), 0); err != nil {
+			t.Fatalf("Immediate: %v", err)
+		}
+
+		var result *Result
+		select {
+		case result = <-listener.Channel():
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for a pass")
+		}
+
+		if result.Err != nil {
+			t.Fatalf("pass %d: %v", result.Pass, result.Err)
+		}
+		passes = append(passes, result.Pass)
+
+		if len(result.Entities) == 0 {
+			continue
+		}
+
+		for _, handle := range handles {
+			if _, ok := result.Entities[handle]; !ok {
+				t.Fatalf("pass %d: missing result for %v", result.Pass, handle)
+			}
+		}
+		break
+	}
+
+	for i := 1; i < len(passes); i++ {
+		if passes[i] != passes[i-1]+1 {
+			t.Fatalf("expected one result per pass, got passes %v", passes)
+		}
+	}
+	if p := peak.Load(); p > 4 {
+		t.Fatalf("entity concurrency exceeded: %d", p)
+	}
+}
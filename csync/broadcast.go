@@ -3,6 +3,7 @@ package csync
 import (
 	"context"
 	"errors"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -60,6 +61,10 @@ type broadcaster[V any] struct {
 	listeners Map[Listener[V], chan<- V]
 	capacity  int
 	closed    atomic.Bool
+
+	// NOTE: held (for reading) while sending, so that no listener channel
+	// gets closed in the middle of a send.
+	lock sync.RWMutex
 }
 
 func NewBroadcaster[V any](capacity int) Broadcaster[V] {
@@ -128,7 +133,9 @@ func (bc *broadcaster[V]) SendWithTimeout(pctx context.Context, value V, timeout
 		}
 	}
 
+	bc.lock.RLock()
 	bc.listeners.Range(cb)
+	bc.lock.RUnlock()
 
 	return r, nil
 }
@@ -154,6 +161,9 @@ func (bc *broadcaster[V]) DiscardListener(l Listener[V]) {
 		return
 	}
 
+	bc.lock.Lock()
+	defer bc.lock.Unlock()
+
 	ch, ok := bc.listeners.LoadAndDelete(l)
 	if !ok {
 		return
@@ -0,0 +1,61 @@
+package csync
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+//////////////////////////////////////////////////
+
+func TestBroadcasterDiscardWhileSending(t *testing.T) {
+	bc := NewBroadcaster[int](1)
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+
+			bc.Send(context.Background(), i, false)
+		}
+	}()
+
+	for i := 0; i < 1000; i++ {
+		l := bc.Listen()
+		l.Discard()
+	}
+
+	close(stop)
+	wg.Wait()
+}
+
+func TestBroadcasterSendDropsForFullListeners(t *testing.T) {
+	bc := NewBroadcaster[int](1)
+
+	l := bc.Listen()
+	defer l.Discard()
+
+	for i := 0; i < 3; i++ {
+		if _, err := bc.Send(context.Background(), i, false); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+
+	if v := <-l.Channel(); v != 0 {
+		t.Fatalf("expected the first value, got %d", v)
+	}
+	select {
+	case v := <-l.Channel():
+		t.Fatalf("unexpected value %d", v)
+	default:
+	}
+}
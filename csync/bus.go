@@ -8,6 +8,10 @@ import (
 
 //////////////////////////////////////////////////
 
+// NOTE: listeners get (at least) one slot, so that the final result of
+// a session is not lost when nobody happens to be receiving right then.
+var BusListenerCapacity = 1
+
 type Bus[T any] struct {
 	sync.RWMutex
 	ready atomic.Bool
@@ -16,8 +20,8 @@ type Bus[T any] struct {
 
 	results   chan T
 	stop      chan struct{}
-	stopped   chan struct{}
 	immediate chan time.Duration
+	wake      chan time.Time
 	paused    chan struct{}
 }
 
@@ -32,21 +36,21 @@ func (bus *Bus[T]) Setup() {
 	defer bus.ready.Store(true)
 
 	if bus.broadcast == nil || bus.broadcast.Closed() {
-		bus.broadcast = NewBroadcaster[T](0)
+		bus.broadcast = NewBroadcaster[T](BusListenerCapacity)
 	}
 
 	if bus.results == nil {
-		bus.results = make(chan T)
+		bus.results = make(chan T, 1)
 	}
 	if bus.stop == nil {
 		bus.stop = make(chan struct{})
 	}
-	if bus.stopped == nil {
-		bus.stopped = make(chan struct{})
-	}
 	if bus.immediate == nil {
 		bus.immediate = make(chan time.Duration)
 	}
+	if bus.wake == nil {
+		bus.wake = make(chan time.Time)
+	}
 	if bus.paused == nil {
 		bus.paused = make(chan struct{})
 	}
@@ -68,8 +72,8 @@ func (bus *Bus[T]) Reset() {
 
 		bus.results = nil
 		bus.stop = nil
-		bus.stopped = nil
 		bus.immediate = nil
+		bus.wake = nil
 		bus.paused = nil
 	}
 }
@@ -85,7 +89,7 @@ func (bus *Bus[T]) Broadcast() Broadcaster[T] {
 	return bus.broadcast
 }
 
-func (bus *Bus[T]) Channels() (results chan T, stop chan struct{}, stopped chan struct{}, immediate chan time.Duration, paused chan struct{}) {
+func (bus *Bus[T]) Channels() (results chan T, stop chan struct{}, immediate chan time.Duration, wake chan time.Time, paused chan struct{}) {
 	if !bus.ready.Load() {
 		bus.Setup()
 	}
@@ -93,5 +97,5 @@ func (bus *Bus[T]) Channels() (results chan T, stop chan struct{}, stopped chan
 	bus.RLock()
 	defer bus.RUnlock()
 
-	return bus.results, bus.stop, bus.stopped, bus.immediate, bus.paused
+	return bus.results, bus.stop, bus.immediate, bus.wake, bus.paused
 }
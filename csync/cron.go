@@ -0,0 +1,320 @@
+package csync
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//////////////////////////////////////////////////
+
+var InvalidCronExpression = errors.New("invalid cron expression")
+
+type CronSchedule struct {
+	expr     string
+	location *time.Location
+
+	second uint64
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+
+	domStar bool
+	dowStar bool
+}
+
+type cronBounds struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronSeconds = cronBounds{0, 59, nil}
+	cronMinutes = cronBounds{0, 59, nil}
+	cronHours   = cronBounds{0, 23, nil}
+	cronDays    = cronBounds{1, 31, nil}
+	cronMonths  = cronBounds{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	cronWeekdays = cronBounds{0, 7, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 0 1 1 *",
+		"@annually": "0 0 0 1 1 *",
+		"@monthly":  "0 0 0 1 * *",
+		"@weekly":   "0 0 0 * * 0",
+		"@daily":    "0 0 0 * * *",
+		"@midnight": "0 0 0 * * *",
+		"@hourly":   "0 0 * * * *",
+	}
+)
+
+// NOTE: the next occurrence is searched for up to this many years ahead;
+// an expression that never matches (e.g., "0 0 30 2 *") yields zero time.
+const cronSearchYears = 5
+
+//////////////////////////////////////////////////
+
+func ParseCron(expr string) (schedule *CronSchedule, err error) {
+	text := strings.TrimSpace(expr)
+
+	var location *time.Location
+
+	if strings.HasPrefix(text, "CRON_TZ=") || strings.HasPrefix(text, "TZ=") {
+		zone, rest, _ := strings.Cut(text, " ")
+		_, name, _ := strings.Cut(zone, "=")
+
+		if location, err = time.LoadLocation(name); err != nil {
+			err = fmt.Errorf("%w: %w", InvalidCronExpression, err)
+			return
+		}
+
+		text = strings.TrimSpace(rest)
+	}
+
+	if strings.HasPrefix(text, "@") {
+		descriptor, ok := cronDescriptors[strings.ToLower(text)]
+		if !ok {
+			err = fmt.Errorf("%w: unknown descriptor %q", InvalidCronExpression, text)
+			return
+		}
+
+		text = descriptor
+	}
+
+	fields := strings.Fields(text)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		err = fmt.Errorf("%w: expected 5 or 6 fields, got %d", InvalidCronExpression, len(fields))
+		return
+	}
+
+	s := &CronSchedule{
+		expr:     expr,
+		location: location,
+	}
+
+	targets := []*uint64{&s.second, &s.minute, &s.hour, &s.dom, &s.month, &s.dow}
+	bounds := []cronBounds{cronSeconds, cronMinutes, cronHours, cronDays, cronMonths, cronWeekdays}
+
+	for i, field := range fields {
+		if *targets[i], err = parseCronField(field, bounds[i]); err != nil {
+			return
+		}
+	}
+
+	// NOTE: both 0 and 7 stand for Sunday.
+	if s.dow&(1<<7) != 0 {
+		s.dow = (s.dow | 1) &^ (1 << 7)
+	}
+
+	s.domStar = isCronWildcard(fields[3])
+	s.dowStar = isCronWildcard(fields[5])
+
+	schedule = s
+	return
+}
+
+func parseCronField(field string, bounds cronBounds) (mask uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		var bits uint64
+		if bits, err = parseCronRange(part, bounds); err != nil {
+			return
+		}
+
+		mask |= bits
+	}
+
+	return
+}
+
+func parseCronRange(part string, bounds cronBounds) (mask uint64, err error) {
+	invalid := func() error {
+		return fmt.Errorf("%w: invalid field %q", InvalidCronExpression, part)
+	}
+
+	text, stepText, stepped := strings.Cut(part, "/")
+
+	step := 1
+	if stepped {
+		if step, err = strconv.Atoi(stepText); err != nil || step < 1 {
+			err = invalid()
+			return
+		}
+	}
+
+	var low, high int
+
+	switch {
+	case isCronWildcard(text):
+		low, high = bounds.min, bounds.max
+
+	case strings.Contains(text, "-"):
+		lowText, highText, _ := strings.Cut(text, "-")
+
+		if low, err = parseCronValue(lowText, bounds); err != nil {
+			err = invalid()
+			return
+		}
+		if high, err = parseCronValue(highText, bounds); err != nil {
+			err = invalid()
+			return
+		}
+
+	default:
+		if low, err = parseCronValue(text, bounds); err != nil {
+			err = invalid()
+			return
+		}
+
+		high = low
+		if stepped {
+			high = bounds.max
+		}
+	}
+
+	if low > high {
+		err = invalid()
+		return
+	}
+
+	for v := low; v <= high; v += step {
+		mask |= 1 << uint(v)
+	}
+
+	return
+}
+
+func parseCronValue(text string, bounds cronBounds) (value int, err error) {
+	if v, ok := bounds.names[strings.ToLower(text)]; ok {
+		return v, nil
+	}
+
+	if value, err = strconv.Atoi(text); err != nil {
+		return
+	}
+
+	if value < bounds.min || value > bounds.max {
+		err = InvalidCronExpression
+	}
+
+	return
+}
+
+func isCronWildcard(text string) bool {
+	return text == "*" || text == "?"
+}
+
+//////////////////////////////////////////////////
+
+func (s *CronSchedule) String() string {
+	return s.expr
+}
+
+func (s *CronSchedule) Location() *time.Location {
+	return s.location
+}
+
+func (s *CronSchedule) Next(now time.Time) time.Time {
+	location := s.location
+	if location == nil {
+		location = now.Location()
+	}
+
+	t := now.In(location).Truncate(time.Second).Add(time.Second)
+	yearLimit := t.Year() + cronSearchYears
+
+	// NOTE: once a field has been advanced, all of the lower fields are
+	// reset to their lowest value (and the search starts over whenever a
+	// higher field wraps around).
+	reset := false
+
+search:
+	for t.Year() <= yearLimit {
+		for !cronHas(s.month, int(t.Month())) {
+			if !reset {
+				reset = true
+				t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, location)
+			}
+
+			t = t.AddDate(0, 1, 0)
+			if t.Month() == time.January {
+				continue search
+			}
+		}
+
+		for !s.dayMatches(t) {
+			if !reset {
+				reset = true
+				t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+			}
+
+			t = t.AddDate(0, 0, 1)
+			if t.Day() == 1 {
+				continue search
+			}
+		}
+
+		for !cronHas(s.hour, t.Hour()) {
+			if !reset {
+				reset = true
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, location)
+			}
+
+			t = t.Add(time.Hour)
+			if t.Hour() == 0 {
+				continue search
+			}
+		}
+
+		for !cronHas(s.minute, t.Minute()) {
+			if !reset {
+				reset = true
+				t = t.Truncate(time.Minute)
+			}
+
+			t = t.Add(time.Minute)
+			if t.Minute() == 0 {
+				continue search
+			}
+		}
+
+		for !cronHas(s.second, t.Second()) {
+			t = t.Add(time.Second)
+			if t.Second() == 0 {
+				continue search
+			}
+		}
+
+		return t.In(now.Location())
+	}
+
+	return time.Time{}
+}
+
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := cronHas(s.dom, t.Day())
+	dowMatch := cronHas(s.dow, int(t.Weekday()))
+
+	// NOTE: if both day fields are restricted, a day matching either one of
+	// them is enough (as in the traditional cron).
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+
+	return domMatch || dowMatch
+}
+
+func cronHas(mask uint64, v int) bool {
+	return mask&(1<<uint(v)) != 0
+}
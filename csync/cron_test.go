@@ -0,0 +1,119 @@
+package csync
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+		"@often",
+		"CRON_TZ=Nowhere/Nothing * * * * *",
+	} {
+		if _, err := ParseCron(expr); !errors.Is(err, InvalidCronExpression) {
+			t.Errorf("ParseCron(%q): expected InvalidCronExpression, got %v", expr, err)
+		}
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	// NOTE: 2026-01-15 is a Thursday.
+	now := time.Date(2026, time.January, 15, 10, 7, 30, 0, time.UTC)
+
+	for _, tc := range []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.January, 15, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.January, 15, 10, 15, 0, 0, time.UTC)},
+		{"*/10 * * * * *", time.Date(2026, time.January, 15, 10, 7, 40, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2026, time.January, 16, 9, 0, 0, 0, time.UTC)},
+		{"30 10 * * mon-fri", time.Date(2026, time.January, 15, 10, 30, 0, 0, time.UTC)},
+		{"0 0 * * sat,sun", time.Date(2026, time.January, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, time.January, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 31 * *", time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1-10/3 mar *", time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, time.January, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+
+		// NOTE: with both day fields restricted, either one of them matching
+		// is enough (here: the 20th, or the next Friday, the 16th).
+		{"0 0 20 * fri", time.Date(2026, time.January, 16, 0, 0, 0, 0, time.UTC)},
+	} {
+		s, err := ParseCron(tc.expr)
+		if err != nil {
+			t.Errorf("ParseCron(%q): %v", tc.expr, err)
+			continue
+		}
+
+		if next := s.Next(now); !next.Equal(tc.next) {
+			t.Errorf("%q: expected %v, got %v", tc.expr, tc.next, next)
+		}
+	}
+}
+
+func TestCronScheduleNextIsStrictlyAfter(t *testing.T) {
+	s, err := ParseCron("0 * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2026, time.January, 15, 10, 0, 0, 0, time.UTC)
+	if next := s.Next(now); !next.Equal(now.Add(time.Hour)) {
+		t.Fatalf("expected the following hour, got %v", next)
+	}
+}
+
+func TestCronScheduleNeverMatches(t *testing.T) {
+	s, err := ParseCron("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if next := s.Next(time.Now()); !next.IsZero() {
+		t.Fatalf("expected zero time, got %v", next)
+	}
+}
+
+func TestCronScheduleLocation(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone database unavailable: %v", err)
+	}
+
+	s, err := ParseCron("CRON_TZ=America/New_York 0 9 * * *")
+	if err != nil {
+		t.Fatalf("ParseCron: %v", err)
+	}
+	if s.Location() != nil && s.Location().String() != location.String() {
+		t.Fatalf("unexpected location %v", s.Location())
+	}
+
+	now := time.Date(2026, time.January, 15, 12, 0, 0, 0, time.UTC)
+	next := s.Next(now)
+
+	if expected := time.Date(2026, time.January, 15, 9, 0, 0, 0, location); !next.Equal(expected) {
+		t.Fatalf("expected %v, got %v", expected, next)
+	}
+	if next.Location() != time.UTC {
+		t.Fatalf("expected the result in the caller's location, got %v", next.Location())
+	}
+}
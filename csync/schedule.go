@@ -0,0 +1,170 @@
+package csync
+
+import (
+	"math/rand"
+	"time"
+)
+
+//////////////////////////////////////////////////
+
+type Schedule interface {
+	Next(now time.Time) time.Time
+}
+
+type ScheduleFunc func(now time.Time) time.Time
+
+func (f ScheduleFunc) Next(now time.Time) time.Time {
+	return f(now)
+}
+
+//////////////////////////////////////////////////
+
+type IntervalSchedule struct {
+	Interval time.Duration
+}
+
+func (s IntervalSchedule) Next(now time.Time) time.Time {
+	if s.Interval <= 0 {
+		return time.Time{}
+	}
+
+	return now.Add(s.Interval)
+}
+
+//////////////////////////////////////////////////
+
+type JitterSchedule struct {
+	Interval time.Duration
+	Jitter   time.Duration
+}
+
+func (s JitterSchedule) Next(now time.Time) time.Time {
+	if s.Interval <= 0 {
+		return time.Time{}
+	}
+
+	delay := s.Interval
+	if s.Jitter > 0 {
+		delay += time.Duration(rand.Int63n(2*int64(s.Jitter)+1)) - s.Jitter
+	}
+	if delay < 0 {
+		delay = 0
+	}
+
+	return now.Add(delay)
+}
+
+//////////////////////////////////////////////////
+
+type SessionMode uint
+
+const (
+	SessionFixedDelay SessionMode = iota
+	SessionFixedRate
+)
+
+func (mode SessionMode) String() string {
+	switch mode {
+	case SessionFixedRate:
+		return "fixed_rate"
+	}
+
+	return "fixed_delay"
+}
+
+//////////////////////////////////////////////////
+
+func (settings *SessionSettings) after(now time.Time, slot *time.Time) (at time.Time, ok bool) {
+	next := settings.next(now, *slot)
+	if next.IsZero() {
+		return
+	}
+
+	// NOTE: with a schedule, the jitter factor is relative to the gap
+	// between passes (rather than to the interval).
+	span := settings.Interval
+	if settings.Schedule != nil {
+		if slot.IsZero() {
+			span = next.Sub(now)
+		} else {
+			span = next.Sub(*slot)
+		}
+	}
+	*slot = next
+
+	return next.Add(settings.jitter(span)), true
+}
+
+// NOTE: a pass requested by a wake-up (or by resuming) is never made ahead
+// of a schedule, and is aligned and jittered just like a regular one, so
+// that sessions which start together do not stay in lockstep.
+func (settings *SessionSettings) wakeAt(at time.Time) (time.Time, bool) {
+	if settings.Schedule != nil {
+		return time.Time{}, false
+	}
+
+	return settings.align(at).Add(settings.jitter(settings.Interval)), true
+}
+
+func (settings *SessionSettings) next(now time.Time, slot time.Time) (next time.Time) {
+	if settings.Schedule != nil {
+		if next = settings.Schedule.Next(now); next.IsZero() {
+			return
+		}
+
+		// NOTE: a schedule cannot make passes any more frequent than the
+		// minimum session interval allows.
+		if earliest := slot.Add(MinimumSessionInterval); !slot.IsZero() && next.Before(earliest) {
+			next = earliest
+		}
+		if next.Before(now) {
+			next = now
+		}
+
+		return
+	}
+
+	switch {
+	case slot.IsZero():
+		next = now
+
+	case settings.Mode == SessionFixedRate:
+		next = slot.Add(settings.Interval)
+
+		// NOTE: slots that have been missed (e.g., because of a pass taking
+		// longer than the interval) are skipped rather than made up for.
+		if next.Before(now) {
+			missed := now.Sub(next)/settings.Interval + 1
+			next = next.Add(missed * settings.Interval)
+		}
+
+	default:
+		next = now.Add(settings.Interval)
+	}
+
+	next = settings.align(next)
+	return
+}
+
+func (settings *SessionSettings) align(t time.Time) time.Time {
+	if settings.Align > 0 {
+		if aligned := t.Truncate(settings.Align); aligned.Before(t) {
+			return aligned.Add(settings.Align)
+		}
+	}
+
+	return t
+}
+
+func (settings *SessionSettings) jitter(span time.Duration) time.Duration {
+	spread := settings.Jitter
+	if settings.JitterFactor > 0 && span > 0 {
+		spread += time.Duration(settings.JitterFactor * float64(span))
+	}
+
+	if spread <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(spread) + 1))
+}
@@ -0,0 +1,209 @@
+package csync
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////
+
+func TestScheduleClampedToMinimumInterval(t *testing.T) {
+	now := time.Now()
+
+	for name, schedule := range map[string]Schedule{
+		"interval": IntervalSchedule{Interval: time.Nanosecond},
+		"past": ScheduleFunc(func(now time.Time) time.Time {
+			return now.Add(-time.Hour)
+		}),
+	} {
+		settings := SessionSettings{Schedule: schedule}
+
+		if next := settings.next(now, time.Time{}); next.Before(now) {
+			t.Errorf("%s: first pass scheduled in the past: %v", name, next)
+		}
+
+		slot := now
+		if next := settings.next(now, slot); next.Before(slot.Add(MinimumSessionInterval)) {
+			t.Errorf("%s: pass scheduled within the minimum interval: %v", name, next.Sub(slot))
+		}
+	}
+}
+
+func TestScheduleRunsOut(t *testing.T) {
+	settings := SessionSettings{Schedule: ScheduleFunc(func(now time.Time) time.Time {
+		return time.Time{}
+	})}
+
+	if next := settings.next(time.Now(), time.Now()); !next.IsZero() {
+		t.Fatalf("expected zero time, got %v", next)
+	}
+}
+
+func TestSessionScheduleHotLoop(t *testing.T) {
+	var sess Session[int64]
+	var passes atomic.Int64
+
+	settings := SessionSettings{Schedule: IntervalSchedule{Interval: time.Nanosecond}}
+	if err := sess.Start(context.Background(), countingHandler(&passes), settings); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	time.Sleep(10 * MinimumSessionInterval)
+	sess.Stop(context.Background())
+
+	if n := passes.Load(); n > 12 {
+		t.Fatalf("expected at most ~10 passes, got %d", n)
+	}
+}
+
+func TestSessionResumeKeepsSchedule(t *testing.T) {
+	var sess Session[int64]
+	var passes atomic.Int64
+
+	settings := SessionSettings{
+		Paused:   true,
+		Schedule: IntervalSchedule{Interval: time.Hour},
+	}
+	if err := sess.Start(context.Background(), countingHandler(&passes), settings); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer sess.Stop(context.Background())
+
+	// NOTE: the run loop has to be waiting for the resume signal.
+	time.Sleep(20 * time.Millisecond)
+
+	sess.Resume(context.Background())
+	time.Sleep(50 * time.Millisecond)
+
+	if n := passes.Load(); n != 0 {
+		t.Fatalf("resuming brought the scheduled pass forward (%d passes)", n)
+	}
+}
+
+func TestSessionWake(t *testing.T) {
+	for name, tc := range map[string]struct {
+		settings SessionSettings
+		passes   int64
+	}{
+		"interval": {SessionSettings{Interval: time.Hour}, 2},
+		"schedule": {SessionSettings{Schedule: IntervalSchedule{Interval: time.Hour}}, 1},
+	} {
+		var sess Session[int64]
+		var passes atomic.Int64
+
+		listener := sess.Listen()
+		if err := sess.Start(context.Background(), countingHandler(&passes), tc.settings); err != nil {
+			t.Fatalf("%s: Start: %v", name, err)
+		}
+
+		// NOTE: a scheduled session would not make its first pass for an
+		// hour otherwise.
+		if tc.settings.Schedule != nil {
+			sess.Immediate(context.Background(), 0)
+		}
+		receive(t, listener)
+
+		if ok, err := sess.Wake(context.Background(), time.Now().Add(10*time.Millisecond)); !ok || err != nil {
+			t.Fatalf("%s: Wake: ok=%v err=%v", name, ok, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+
+		if n := passes.Load(); n != tc.passes {
+			t.Errorf("%s: expected %d passes, got %d", name, tc.passes, n)
+		}
+
+		sess.Stop(context.Background())
+		listener.Discard()
+	}
+}
+
+func TestSessionFixedRateSkipsMissedSlots(t *testing.T) {
+	settings := SessionSettings{Mode: SessionFixedRate, Interval: 10 * time.Second}
+	slot := time.Now()
+
+	if next := settings.next(slot.Add(5*time.Second), slot); !next.Equal(slot.Add(10 * time.Second)) {
+		t.Errorf("expected the next slot, got %v", next.Sub(slot))
+	}
+	if next := settings.next(slot.Add(35*time.Second), slot); !next.Equal(slot.Add(40 * time.Second)) {
+		t.Errorf("expected missed slots to be skipped, got %v", next.Sub(slot))
+	}
+}
+
+func TestSessionAlign(t *testing.T) {
+	settings := SessionSettings{Interval: 7 * time.Second, Align: 10 * time.Second}
+	base := time.Now().Truncate(settings.Align)
+
+	for _, now := range []time.Time{base, base.Add(3 * time.Second), base.Add(4 * time.Second)} {
+		next := settings.next(now, now)
+		if !next.Equal(next.Truncate(settings.Align)) {
+			t.Errorf("pass not aligned: %v", next.Sub(base))
+		}
+		if next.Before(now.Add(settings.Interval)) || !next.Before(now.Add(settings.Interval+settings.Align)) {
+			t.Errorf("pass aligned too far: %v after %v", next.Sub(base), now.Sub(base))
+		}
+	}
+
+	now := base.Add(time.Second)
+	if at, ok := settings.wakeAt(now); !ok || !at.Equal(base.Add(settings.Align)) {
+		t.Errorf("wake-up not aligned: ok=%v at=%v", ok, at.Sub(base))
+	}
+}
+
+func TestSessionJitterBounds(t *testing.T) {
+	for name, tc := range map[string]struct {
+		settings SessionSettings
+		spread   time.Duration
+	}{
+		"interval": {SessionSettings{Interval: 10 * time.Second, Jitter: time.Second, JitterFactor: 0.5}, 6 * time.Second},
+		"schedule": {SessionSettings{Schedule: IntervalSchedule{Interval: 10 * time.Second}, JitterFactor: 0.1}, time.Second},
+	} {
+		now := time.Now()
+		slot := now
+		jittered := false
+
+		for i := 0; i < 100; i++ {
+			at, ok := tc.settings.after(now, &slot)
+			if !ok {
+				t.Fatalf("%s: schedule ran out", name)
+			}
+			if offset := at.Sub(slot); offset < 0 || offset > tc.spread {
+				t.Fatalf("%s: jitter out of bounds: %v", name, offset)
+			} else if offset > 0 {
+				jittered = true
+			}
+
+			now = slot
+		}
+
+		if !jittered {
+			t.Errorf("%s: no jitter applied", name)
+		}
+	}
+}
+
+func TestSessionResumeKeepsAlignment(t *testing.T) {
+	var sess Session[int64]
+	var passes atomic.Int64
+
+	settings := SessionSettings{
+		Paused:   true,
+		Interval: time.Hour,
+		Align:    time.Hour,
+	}
+	if err := sess.Start(context.Background(), countingHandler(&passes), settings); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer sess.Stop(context.Background())
+
+	// NOTE: the run loop has to be waiting for the resume signal.
+	time.Sleep(20 * time.Millisecond)
+
+	sess.Resume(context.Background())
+	time.Sleep(50 * time.Millisecond)
+
+	if n := passes.Load(); n != 0 {
+		t.Fatalf("resuming made a pass off the alignment boundary (%d passes)", n)
+	}
+}
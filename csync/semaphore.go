@@ -0,0 +1,73 @@
+package csync
+
+import (
+	"context"
+	"sync"
+)
+
+//////////////////////////////////////////////////
+
+type Semaphore struct {
+	lock     sync.Mutex
+	count    int
+	released chan struct{}
+}
+
+func (s *Semaphore) Acquire(ctx context.Context, limit int) error {
+	if s == nil {
+		return nil
+	}
+
+	for {
+		s.lock.Lock()
+		if limit < 1 || s.count < limit {
+			s.count++
+			s.lock.Unlock()
+
+			return nil
+		}
+
+		if s.released == nil {
+			s.released = make(chan struct{})
+		}
+		released := s.released
+		s.lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		case <-released:
+		}
+	}
+}
+
+func (s *Semaphore) Release() {
+	if s == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.count > 0 {
+		s.count--
+	}
+
+	// NOTE: the limit is given on each acquisition (and might change
+	// between them), so every waiter gets woken up to re-check it.
+	if s.released != nil {
+		close(s.released)
+		s.released = nil
+	}
+}
+
+func (s *Semaphore) Count() int {
+	if s == nil {
+		return 0
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.count
+}
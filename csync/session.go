@@ -3,6 +3,7 @@ package csync
 import (
 	"context"
 	"errors"
+	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -12,6 +13,9 @@ import (
 var (
 	SessionAlreadyActive   = errors.New("session is already active")
 	SessionInvalidInterval = errors.New("invalid session interval")
+	SessionInvalidJitter   = errors.New("invalid session jitter")
+	SessionInvalidAlign    = errors.New("invalid session alignment")
+	SessionInvalidMode     = errors.New("invalid session mode")
 
 	ExceededSessionPassTimeout = errors.New("exceeded session pass timeout")
 )
@@ -21,14 +25,18 @@ var (
 )
 
 type Session[T any] struct {
-	active    atomic.Bool
+	state     atomic.Uint32
 	paused    atomic.Bool
 	pauseIdle atomic.Bool
+	resumed   atomic.Bool
 
 	id   string
 	pass uint64
 
 	bus Bus[T]
+
+	lock sync.Mutex
+	done chan struct{}
 }
 
 type Handler[T any] func(ctx context.Context, sess *Session[T]) T
@@ -39,12 +47,62 @@ type SessionSettings struct {
 
 	Paused    bool `json:"paused"`
 	PauseIdle bool `json:"pause_idle"`
+
+	DrainTimeout time.Duration `json:"drain_timeout"`
+
+	Schedule Schedule `json:"-"`
+
+	Jitter       time.Duration `json:"jitter"`
+	JitterFactor float64       `json:"jitter_factor"`
+	Align        time.Duration `json:"align"`
+	Mode         SessionMode   `json:"mode"`
+}
+
+//////////////////////////////////////////////////
+
+type SessionState uint32
+
+const (
+	SessionIdle SessionState = iota
+	SessionRunning
+	SessionPaused
+	SessionStopping
+	SessionStopped
+)
+
+func (state SessionState) String() string {
+	switch state {
+	case SessionRunning:
+		return "running"
+	case SessionPaused:
+		return "paused"
+	case SessionStopping:
+		return "stopping"
+	case SessionStopped:
+		return "stopped"
+	}
+
+	return "idle"
 }
 
 //////////////////////////////////////////////////
 
+func (sess *Session[T]) State() SessionState {
+	state := SessionState(sess.state.Load())
+	if state == SessionRunning && sess.paused.Load() {
+		return SessionPaused
+	}
+
+	return state
+}
+
 func (sess *Session[T]) Active() bool {
-	return sess.active.Load()
+	switch SessionState(sess.state.Load()) {
+	case SessionRunning, SessionStopping:
+		return true
+	}
+
+	return false
 }
 
 func (sess *Session[T]) ID() string {
@@ -65,13 +123,9 @@ func (sess *Session[T]) Listen() (listener Listener[T]) {
 	return broadcast.Listen()
 }
 
-func (sess *Session[T]) halt(ctx context.Context) {
-	if ctx != nil && ctx.Err() != nil {
-		return
-	}
-
-	sess.active.Store(false)
+func (sess *Session[T]) halt() {
 	sess.bus.Reset()
+	sess.state.Store(uint32(SessionStopped))
 }
 
 func (sess *Session[T]) PauseIdle() bool {
@@ -87,6 +141,10 @@ func (sess *Session[T]) Paused() bool {
 }
 
 func (sess *Session[T]) SetPaused(ctx context.Context, paused bool) {
+	if !paused {
+		sess.resumed.Store(true)
+	}
+
 	if sess.paused.Swap(paused) != paused {
 		_, _, _, _, ch := sess.bus.Channels()
 
@@ -114,11 +172,11 @@ func (sess *Session[T]) Immediate(parentCtx context.Context, in time.Duration) (
 		return
 	}
 
-	if !sess.active.Load() {
+	if !sess.Active() {
 		return
 	}
 
-	_, _, _, immediate, _ := sess.bus.Channels()
+	_, _, immediate, _, _ := sess.bus.Channels()
 	if immediate == nil {
 		return
 	}
@@ -138,6 +196,40 @@ func (sess *Session[T]) Immediate(parentCtx context.Context, in time.Duration) (
 	return
 }
 
+// NOTE: unlike Immediate, a wake-up only ever brings the next pass forward
+// as far as the session's settings allow (e.g., not at all, if the session
+// follows a schedule).
+func (sess *Session[T]) Wake(parentCtx context.Context, at time.Time) (ok bool, err error) {
+	if parentCtx == nil {
+		parentCtx = context.Background()
+	} else if err = parentCtx.Err(); err != nil {
+		return
+	}
+
+	if !sess.Active() {
+		return
+	}
+
+	_, _, _, wake, _ := sess.bus.Channels()
+	if wake == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	select {
+	case wake <- at:
+
+	case <-ctx.Done():
+		err = ctx.Err()
+		return
+	}
+
+	ok = true
+	return
+}
+
 func (sess *Session[T]) Stop(parentCtx context.Context) (ok bool, err error) {
 	if parentCtx == nil {
 		parentCtx = context.Background()
@@ -145,29 +237,39 @@ func (sess *Session[T]) Stop(parentCtx context.Context) (ok bool, err error) {
 		return
 	}
 
-	if !sess.active.Load() {
+	sess.lock.Lock()
+	if !sess.Active() {
+		sess.lock.Unlock()
 		return
 	}
+	done := sess.done
+	sess.lock.Unlock()
 
-	_, stop, stopped, _, _ := sess.bus.Channels()
-	if stop == nil || stopped == nil {
+	_, stop, _, _, _ := sess.bus.Channels()
+	if stop == nil || done == nil {
 		// NOTE: this should not happen.
 		return
 	}
 
+	sess.state.CompareAndSwap(uint32(SessionRunning), uint32(SessionStopping))
+
 	ctx, cancel := context.WithCancel(parentCtx)
 	defer cancel()
 
+	// NOTE: the run loop might be exiting on its own (e.g., because its
+	// context got cancelled), in which case nobody receives the stop signal.
 	select {
 	case stop <- struct{}{}:
 
+	case <-done:
+
 	case <-ctx.Done():
 		err = ctx.Err()
 		return
 	}
 
 	select {
-	case <-stopped:
+	case <-done:
 
 	case <-ctx.Done():
 		err = ctx.Err()
@@ -185,29 +287,110 @@ func (sess *Session[T]) Start(ctx context.Context, handler Handler[T], settings
 		return err
 	}
 
-	if settings.Interval < MinimumSessionInterval {
+	if settings.Schedule == nil && settings.Interval < MinimumSessionInterval {
 		return SessionInvalidInterval
 	}
 
-	if sess.active.Swap(true) {
+	if settings.Jitter < 0 || settings.JitterFactor < 0 {
+		return SessionInvalidJitter
+	}
+
+	if settings.Align < 0 {
+		return SessionInvalidAlign
+	}
+
+	switch settings.Mode {
+	case SessionFixedDelay, SessionFixedRate:
+	default:
+		return SessionInvalidMode
+	}
+
+	done := make(chan struct{})
+
+	// NOTE: the state and the done channel of a run are published together,
+	// so that Stop never gets to wait on the done channel of a previous run.
+	sess.lock.Lock()
+	if !sess.state.CompareAndSwap(uint32(SessionIdle), uint32(SessionRunning)) &&
+		!sess.state.CompareAndSwap(uint32(SessionStopped), uint32(SessionRunning)) {
+		sess.lock.Unlock()
 		return SessionAlreadyActive
 	}
+	sess.done = done
+	sess.lock.Unlock()
+
 	sess.paused.Store(settings.Paused)
 	sess.pauseIdle.Store(settings.PauseIdle)
 
 	sess.id = uniqueHex()
 	sess.pass = 0
 
-	go sess.run(ctx, handler, settings.Interval, settings.SinglePassTimeout)
+	go sess.run(ctx, handler, settings, done)
 
 	return nil
 }
 
-func (sess *Session[T]) run(parentCtx context.Context, handler Handler[T], interval time.Duration, singlePassTimeout time.Duration) {
+func (sess *Session[T]) run(parentCtx context.Context, handler Handler[T], settings SessionSettings, done chan<- struct{}) {
+	defer close(done)
+
+	// NOTE: the time the next pass is due at is kept along with its
+	// cooldown, so that wake-ups can tell whether they would bring it
+	// forward (it is zero if no pass is going to be made on its own).
 	var cooldown <-chan time.Time
+	var cooldownAt, pendingWake time.Time
+
+	wait := func(at time.Time) {
+		cooldown, cooldownAt = time.After(time.Until(at)), at
+	}
+
+	var slot time.Time
+	schedule := func(now time.Time) {
+		if at, ok := settings.after(now, &slot); ok {
+			wait(at)
+		} else {
+			// NOTE: the schedule has run out, so no further passes are
+			// made (unless requested explicitly).
+			cooldown, cooldownAt = make(chan time.Time), time.Time{}
+		}
+	}
+
+	// NOTE: even the first pass waits for its turn (which is right away,
+	// unless a schedule, alignment or jitter says otherwise).
+	schedule(time.Now())
 
 	broadcast := sess.bus.Broadcast()
-	results, stop, stopped, immediate, paused := sess.bus.Channels()
+	results, stop, immediate, wake, paused := sess.bus.Channels()
+
+	// NOTE: at most one pass is in flight at any time; requests for another
+	// one (made while it is running) are deferred until it has finished.
+	var cancelPass context.CancelFunc
+	inFlight, rerun := false, false
+
+	spawn := func() {
+		var handlerCtx context.Context
+		var cancel context.CancelFunc
+
+		if settings.SinglePassTimeout > 0 {
+			handlerCtx, cancel = context.WithTimeoutCause(parentCtx, settings.SinglePassTimeout, ExceededSessionPassTimeout)
+		} else {
+			handlerCtx, cancel = context.WithCancel(parentCtx)
+		}
+
+		cancelPass = cancel
+		inFlight = true
+
+		go func(ctx context.Context, cancel context.CancelFunc, sess *Session[T], handler Handler[T], results chan<- T) {
+			defer cancel()
+
+			results <- handler(ctx, sess)
+		}(handlerCtx, cancel, sess, handler, results)
+	}
+
+	receive := func(result T) {
+		inFlight = false
+		cancelPass()
+
+		sess.deliver(parentCtx, broadcast, result)
+	}
 
 handleLoop:
 	for {
@@ -215,22 +398,9 @@ handleLoop:
 			break handleLoop
 		}
 
-		if !sess.paused.Load() {
-			if cooldown == nil {
-				go func(ctx context.Context, sess *Session[T], handler Handler[T], results chan<- T, singlePassTimeout time.Duration) {
-					var handlerCtx context.Context
-					var cancel context.CancelFunc
-
-					if singlePassTimeout > 0 {
-						handlerCtx, cancel = context.WithTimeoutCause(ctx, singlePassTimeout, ExceededSessionPassTimeout)
-					} else {
-						handlerCtx, cancel = context.WithCancel(ctx)
-					}
-					defer cancel()
-
-					results <- handler(handlerCtx, sess)
-				}(parentCtx, sess, handler, results, singlePassTimeout)
-			}
+		if !sess.paused.Load() && !inFlight && cooldown == nil {
+			sess.resumed.Store(false)
+			spawn()
 		}
 
 		select {
@@ -238,61 +408,132 @@ handleLoop:
 			break handleLoop
 
 		case <-cooldown:
-			cooldown = nil
+			cooldown, cooldownAt = nil, time.Time{}
+			rerun = rerun || inFlight
 			continue
 
 		case t := <-immediate:
 			if t <= 0 {
-				cooldown = nil
+				cooldown, cooldownAt = nil, time.Time{}
+				rerun = rerun || inFlight
 			} else {
-				cooldown = time.After(t)
+				wait(time.Now().Add(t))
 			}
 			continue
 
-		case <-paused:
-			cooldown = nil
-			continue
+		case at := <-wake:
+			at, ok := settings.wakeAt(at)
+			if !ok || sess.paused.Load() {
+				continue
+			}
 
-		case <-parentCtx.Done():
+			// NOTE: a wake-up that comes while a pass is in flight is
+			// honored once the pass has finished.
+			if inFlight {
+				if pendingWake.IsZero() || at.Before(pendingWake) {
+					pendingWake = at
+				}
+			} else if cooldownAt.IsZero() || at.Before(cooldownAt) {
+				wait(at)
+			}
+			continue
 
-		case result := <-results:
-			valid := true
-			if v, ok := any(result).(interface{ IsValid() bool }); ok {
-				valid = v.IsValid()
+		case <-paused:
+			cooldown, cooldownAt = nil, time.Time{}
+			if sess.paused.Load() {
+				continue
 			}
 
-			if valid {
-				if broadcast != nil {
-					broadcast.Send(parentCtx, result, false)
-				}
+			// NOTE: resuming a scheduled (or fixed-rate) session does not
+			// bring the next pass forward; otherwise, the pass is made right
+			// away (as far as alignment and jitter allow).
+			now := time.Now()
+			if settings.Schedule != nil || settings.Mode == SessionFixedRate {
+				schedule(now)
+			} else if at, _ := settings.wakeAt(now); at.After(now) {
+				wait(at)
+			}
+			continue
 
-				if sess.pauseIdle.Load() {
-					idle := false
-					if v, ok := any(result).(interface{ IsIdle() bool }); ok {
-						idle = v.IsIdle()
-					}
+		case <-parentCtx.Done():
 
-					if idle {
-						sess.paused.Store(true)
-					}
-				}
+		case result := <-results:
+			receive(result)
 
-				sess.IncrementPass()
+			if rerun {
+				rerun = false
+				cooldown, cooldownAt, pendingWake = nil, time.Time{}, time.Time{}
+				continue
 			}
 		}
 
 		if !sess.paused.Load() {
-			cooldown = time.After(interval)
+			schedule(time.Now())
+
+			if !pendingWake.IsZero() && (cooldownAt.IsZero() || pendingWake.Before(cooldownAt)) {
+				wait(pendingWake)
+			}
 		} else {
-			cooldown = nil
+			cooldown, cooldownAt = nil, time.Time{}
 		}
+		pendingWake = time.Time{}
 	}
 
-	select {
-	case stopped <- struct{}{}:
-		sess.halt(parentCtx)
-	default:
+	sess.state.Store(uint32(SessionStopping))
+
+	if inFlight && settings.DrainTimeout > 0 {
+		// NOTE: the pass in flight is given a chance to finish (and its
+		// result is still broadcast) until the drain timeout runs out.
+		timer := time.NewTimer(settings.DrainTimeout)
+		defer timer.Stop()
+
+		select {
+		case result := <-results:
+			receive(result)
+
+		case <-timer.C:
+		}
+	}
+
+	if inFlight {
+		// NOTE: a pass that is still in flight is cancelled and abandoned
+		// (its result goes to the buffered results channel, which nobody
+		// reads anymore), so that stopping never hangs on a handler that
+		// ignores cancellation.
+		cancelPass()
 	}
 
+	sess.halt()
+
 	return
 }
+
+func (sess *Session[T]) deliver(parentCtx context.Context, broadcast Broadcaster[T], result T) {
+	valid := true
+	if v, ok := any(result).(interface{ IsValid() bool }); ok {
+		valid = v.IsValid()
+	}
+
+	if !valid {
+		return
+	}
+
+	if broadcast != nil {
+		// NOTE: results are broadcast even when the session is being stopped
+		// (possibly because of its context getting cancelled).
+		broadcast.Send(context.WithoutCancel(parentCtx), result, false)
+	}
+
+	if sess.pauseIdle.Load() {
+		idle := false
+		if v, ok := any(result).(interface{ IsIdle() bool }); ok {
+			idle = v.IsIdle()
+		}
+
+		if idle && !sess.resumed.Load() {
+			sess.paused.Store(true)
+		}
+	}
+
+	sess.IncrementPass()
+}
@@ -0,0 +1,302 @@
+package csync
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////
+
+func TestMain(m *testing.M) {
+	MinimumSessionInterval = 10 * time.Millisecond
+
+	os.Exit(m.Run())
+}
+
+func waitForState[T any](t *testing.T, sess *Session[T], state SessionState) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for sess.State() != state {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for state %v (is %v)", state, sess.State())
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func countingHandler(passes *atomic.Int64) Handler[int64] {
+	return func(ctx context.Context, sess *Session[int64]) int64 {
+		return passes.Add(1)
+	}
+}
+
+func receive[T any](t *testing.T, listener Listener[T]) (v T) {
+	t.Helper()
+
+	select {
+	case v = <-listener.Channel():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for a result")
+	}
+
+	return
+}
+
+//////////////////////////////////////////////////
+
+func TestSessionContextCancellation(t *testing.T) {
+	var sess Session[int64]
+	var passes atomic.Int64
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	listener := sess.Listen()
+	if err := sess.Start(ctx, countingHandler(&passes), SessionSettings{Interval: 10 * time.Millisecond}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	receive(t, listener)
+
+	cancel()
+	waitForState(t, &sess, SessionStopped)
+
+	if sess.Active() {
+		t.Fatalf("session is still active after its context was cancelled")
+	}
+
+	// NOTE: stopping a session that has already stopped on its own is a no-op.
+	if ok, err := sess.Stop(context.Background()); ok || err != nil {
+		t.Fatalf("Stop: ok=%v err=%v", ok, err)
+	}
+
+	after := passes.Load()
+	time.Sleep(50 * time.Millisecond)
+	if passes.Load() != after {
+		t.Fatalf("passes kept running after the context was cancelled")
+	}
+}
+
+func TestSessionStop(t *testing.T) {
+	var sess Session[int64]
+	var passes atomic.Int64
+
+	listener := sess.Listen()
+	if err := sess.Start(context.Background(), countingHandler(&passes), SessionSettings{Interval: 10 * time.Millisecond}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	receive(t, listener)
+
+	if err := sess.Start(context.Background(), countingHandler(&passes), SessionSettings{Interval: 10 * time.Millisecond}); err != SessionAlreadyActive {
+		t.Fatalf("expected SessionAlreadyActive, got %v", err)
+	}
+
+	ok, err := sess.Stop(context.Background())
+	if !ok || err != nil {
+		t.Fatalf("Stop: ok=%v err=%v", ok, err)
+	}
+
+	if state := sess.State(); state != SessionStopped {
+		t.Fatalf("expected the session to be stopped, got %v", state)
+	}
+
+	after := passes.Load()
+	time.Sleep(50 * time.Millisecond)
+	if passes.Load() != after {
+		t.Fatalf("passes kept running after the session was stopped")
+	}
+}
+
+func TestSessionRestart(t *testing.T) {
+	var sess Session[int64]
+	var passes atomic.Int64
+
+	for i := 0; i < 3; i++ {
+		listener := sess.Listen()
+
+		if err := sess.Start(context.Background(), countingHandler(&passes), SessionSettings{Interval: 10 * time.Millisecond}); err != nil {
+			t.Fatalf("Start #%d: %v", i, err)
+		}
+		receive(t, listener)
+
+		if ok, err := sess.Stop(context.Background()); !ok || err != nil {
+			t.Fatalf("Stop #%d: ok=%v err=%v", i, ok, err)
+		}
+		listener.Discard()
+	}
+
+	if passes.Load() < 3 {
+		t.Fatalf("expected at least 3 passes, got %d", passes.Load())
+	}
+}
+
+func TestSessionStopRacingStart(t *testing.T) {
+	var sess Session[int64]
+	var passes atomic.Int64
+
+	// NOTE: a stop racing with a restart must never report success because
+	// of the previous run, while leaving the new one running.
+	for i := 0; i < 200; i++ {
+		started := make(chan error, 1)
+		go func() {
+			started <- sess.Start(context.Background(), countingHandler(&passes), SessionSettings{Interval: 10 * time.Millisecond})
+		}()
+
+		ok, err := sess.Stop(context.Background())
+		if err != nil {
+			t.Fatalf("Stop #%d: %v", i, err)
+		}
+
+		if err = <-started; err != nil {
+			t.Fatalf("Start #%d: %v", i, err)
+		}
+
+		if ok {
+			if state := sess.State(); state != SessionStopped {
+				t.Fatalf("Stop #%d: reported success, but the session is %v", i, state)
+			}
+
+			continue
+		}
+
+		if ok, err = sess.Stop(context.Background()); !ok || err != nil {
+			t.Fatalf("Stop #%d: ok=%v err=%v", i, ok, err)
+		}
+	}
+}
+
+//////////////////////////////////////////////////
+
+func startBlockedSession(t *testing.T, sess *Session[int64], settings SessionSettings, handler Handler[int64]) (entered chan struct{}) {
+	t.Helper()
+
+	entered = make(chan struct{})
+	var once atomic.Bool
+
+	if settings.Interval == 0 {
+		settings.Interval = time.Hour
+	}
+
+	err := sess.Start(context.Background(), func(ctx context.Context, sess *Session[int64]) int64 {
+		if once.CompareAndSwap(false, true) {
+			close(entered)
+		}
+
+		return handler(ctx, sess)
+	}, settings)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	select {
+	case <-entered:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the pass to start")
+	}
+
+	return
+}
+
+func TestSessionDrainFinishesPass(t *testing.T) {
+	var sess Session[int64]
+	release := make(chan struct{})
+
+	listener := sess.Listen()
+	startBlockedSession(t, &sess, SessionSettings{DrainTimeout: 5 * time.Second}, func(ctx context.Context, sess *Session[int64]) int64 {
+		<-release
+		return 42
+	})
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		if ok, err := sess.Stop(context.Background()); !ok || err != nil {
+			t.Errorf("Stop: ok=%v err=%v", ok, err)
+		}
+	}()
+
+	waitForState(t, &sess, SessionStopping)
+	close(release)
+	<-stopped
+
+	if v := receive(t, listener); v != 42 {
+		t.Fatalf("expected the drained result, got %d", v)
+	}
+	if state := sess.State(); state != SessionStopped {
+		t.Fatalf("expected the session to be stopped, got %v", state)
+	}
+}
+
+func TestSessionDrainTimeoutCancelsPass(t *testing.T) {
+	var sess Session[int64]
+	cancelled := make(chan struct{})
+
+	startBlockedSession(t, &sess, SessionSettings{DrainTimeout: 20 * time.Millisecond}, func(ctx context.Context, sess *Session[int64]) int64 {
+		<-ctx.Done()
+		close(cancelled)
+		return 0
+	})
+
+	if ok, err := sess.Stop(context.Background()); !ok || err != nil {
+		t.Fatalf("Stop: ok=%v err=%v", ok, err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("pass was not cancelled")
+	}
+}
+
+func TestSessionDrainAbandonsStuckPass(t *testing.T) {
+	var sess Session[int64]
+	release := make(chan struct{})
+	defer close(release)
+
+	startBlockedSession(t, &sess, SessionSettings{DrainTimeout: 20 * time.Millisecond}, func(ctx context.Context, sess *Session[int64]) int64 {
+		// NOTE: this handler ignores cancellation.
+		<-release
+		return 0
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if ok, err := sess.Stop(ctx); !ok || err != nil {
+		t.Fatalf("Stop: ok=%v err=%v", ok, err)
+	}
+	if state := sess.State(); state != SessionStopped {
+		t.Fatalf("expected the session to be stopped, got %v", state)
+	}
+
+	var passes atomic.Int64
+	if err := sess.Start(context.Background(), countingHandler(&passes), SessionSettings{Interval: time.Hour}); err != nil {
+		t.Fatalf("Start after an abandoned pass: %v", err)
+	}
+	sess.Stop(context.Background())
+}
+
+func TestSessionStopWithoutDrain(t *testing.T) {
+	var sess Session[int64]
+	cancelled := make(chan struct{})
+
+	startBlockedSession(t, &sess, SessionSettings{}, func(ctx context.Context, sess *Session[int64]) int64 {
+		<-ctx.Done()
+		close(cancelled)
+		return 0
+	})
+
+	if ok, err := sess.Stop(context.Background()); !ok || err != nil {
+		t.Fatalf("Stop: ok=%v err=%v", ok, err)
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("pass was not cancelled")
+	}
+}
@@ -2,7 +2,9 @@ package crawly
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/rubpy/crawly/clog"
@@ -12,14 +14,86 @@ import (
 
 type Entity struct {
 	Attempt        int       `json:"attempt"`
+	Failures       int       `json:"failures"`
 	LastProcessing time.Time `json:"last_processing"`
+	NextRun        time.Time `json:"next_run"`
+	Panics         int       `json:"panics"`
 
-	Handle Handle `json:"handle"`
-	Data   any    `json:"data"`
+	Handle   Handle            `json:"handle"`
+	Data     any               `json:"data"`
+	Settings *SettingsOverride `json:"settings,omitempty"`
+
+	Tags      []string      `json:"tags,omitempty"`
+	Priority  int           `json:"priority,omitempty"`
+	ExpiresAt time.Time     `json:"expires_at"`
+	MaxAge    time.Duration `json:"max_age,omitempty"`
+
+	Suspended      bool      `json:"suspended,omitempty"`
+	SuspendedUntil time.Time `json:"suspended_until"`
 }
 
 type EntityHandler func(ctx context.Context, entity *Entity, result *TrackingResult) error
 
+func (e Entity) MarshalJSON() ([]byte, error) {
+	type entity Entity
+
+	h, err := marshalHandleJSON(e.Handle)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(struct {
+		entity
+		Handle json.RawMessage `json:"handle"`
+	}{entity(e), h})
+}
+
+func (e *Entity) UnmarshalJSON(data []byte) (err error) {
+	type entity Entity
+
+	aux := struct {
+		*entity
+		Handle json.RawMessage `json:"handle"`
+	}{entity: (*entity)(e)}
+	if err = json.Unmarshal(data, &aux); err != nil {
+		return
+	}
+
+	e.Handle, err = unmarshalHandleJSON(aux.Handle)
+	return
+}
+
+func (e *Entity) HasTags(tags ...string) bool {
+	for _, tag := range tags {
+		if !slices.Contains(e.Tags, tag) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e *Entity) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+func (e *Entity) SuspendedAt(now time.Time) bool {
+	return e.Suspended && (e.SuspendedUntil.IsZero() || now.Before(e.SuspendedUntil))
+}
+
+func (e *Entity) due() time.Time {
+	next := e.NextRun
+	if e.Suspended {
+		next = e.SuspendedUntil
+	}
+
+	if !e.ExpiresAt.IsZero() && (next.IsZero() || e.ExpiresAt.Before(next)) {
+		return e.ExpiresAt
+	}
+
+	return next
+}
+
 //////////////////////////////////////////////////
 
 func (cr *Crawler) processEntity(parentCtx context.Context, entity *Entity, result *TrackingResult) (err error) {
@@ -32,7 +106,7 @@ func (cr *Crawler) processEntity(parentCtx context.Context, entity *Entity, resu
 	var ctx context.Context
 	var cancel context.CancelFunc
 
-	settings := cr.loadSettings()
+	settings := cr.resolveSettings(entity.Handle, entity.Settings)
 
 	timeout := settings.TrackingTimeout
 	if timeout > 0 {
@@ -42,7 +116,29 @@ func (cr *Crawler) processEntity(parentCtx context.Context, entity *Entity, resu
 	}
 	defer cancel()
 
-	if !result.Entity.Value.LastProcessing.IsZero() {
+	if entity.Expired(time.Now()) {
+		cr.expireEntity(parentCtx, entity, result)
+
+		return
+	}
+
+	if entity.Suspended {
+		if entity.SuspendedAt(time.Now()) {
+			return
+		}
+
+		// NOTE: the suspension has run out, so the entity is processed right
+		// away (regardless of when it was supposed to run next).
+		result.Entity.Value.Suspended = false
+		result.Entity.Value.SuspendedUntil = time.Time{}
+		result.Entity.Value.NextRun = time.Time{}
+	}
+
+	if !result.Entity.Value.NextRun.IsZero() {
+		if time.Now().Before(result.Entity.Value.NextRun) {
+			return
+		}
+	} else if !result.Entity.Value.LastProcessing.IsZero() {
 		elapsed := time.Now().Sub(result.Entity.Value.LastProcessing)
 
 		if elapsed < settings.MinimumTrackingDelay {
@@ -55,22 +151,49 @@ func (cr *Crawler) processEntity(parentCtx context.Context, entity *Entity, resu
 		maxAttempts = -1
 	}
 
+	result.Entity.Value.NextRun = time.Time{}
+
+	previous := result.Entity.Value.Data
+
 	handlers := cr.loadHandlers()
-	if handlers.Entity != nil {
-		result.Entity.Err = handlers.Entity(ctx, &result.Entity.Value, result)
+	if handler := cr.entityHandler(handlers); handler != nil {
+		result.Entity.Err = cr.callEntityHandler(ctx, handler, &result.Entity.Value, result)
 	} else {
 		result.Entity.Err = NilHandler
 	}
 
-	if result.Entity.Err != nil {
-		result.Entity.Value.Attempt++
+	if result.Entity.Err == nil {
+		current := result.Entity.Value.Data
+
+		if cr.callChangeDetector(ctx, handlers.ChangeDetector, result.Entity.Value.Handle, previous, current) {
+			result.Change = &DataChange{
+				Previous: previous,
+				Current:  current,
+			}
+		}
+	}
+
+	settlement := settleAttempt(result.Entity.Err, result.Entity.Value.Attempt, result.Entity.Value.Failures, maxAttempts)
+	result.Entity.Value.Attempt = settlement.attempt
+	result.Entity.Value.Failures = settlement.failures
+
+	if IsHandlerPanic(result.Entity.Err) {
+		result.Entity.Value.Panics++
+
+		// NOTE: an entity whose handler keeps panicking is quarantined, i.e.,
+		// suspended until it gets unsuspended explicitly.
+		if settings.MaximumTrackingPanics > 0 && result.Entity.Value.Panics >= settings.MaximumTrackingPanics {
+			result.Entity.Value.Suspended = true
+			result.Entity.Value.SuspendedUntil = time.Time{}
+		}
 	} else {
-		result.Entity.Value.Attempt = 0
+		result.Entity.Value.Panics = 0
 	}
 
 	if result.Entity.Err != nil {
-		if result.Entity.Err == InvalidHandle || (maxAttempts > 0 && result.Entity.Value.Attempt >= maxAttempts) {
+		if settlement.remove {
 			result.Entity.Action = TrackingActionRemove
+			result.Entity.Reason = settlement.reason
 		} else if result.Entity.Action == TrackingActionNone {
 			result.Entity.Action = TrackingActionUpdate
 		}
@@ -81,6 +204,11 @@ func (cr *Crawler) processEntity(parentCtx context.Context, entity *Entity, resu
 	}
 
 	result.Entity.Value.LastProcessing = time.Now()
+	if result.Entity.Value.NextRun.IsZero() {
+		delay := settlement.delay(settings.TrackingBackoff, settings.MinimumTrackingDelay)
+
+		result.Entity.Value.NextRun = result.Entity.Value.LastProcessing.Add(delay)
+	}
 
 	{
 		lp := clog.Params{
@@ -0,0 +1,232 @@
+package crawly
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+//////////////////////////////////////////////////
+
+type PermanentError struct {
+	Err error
+}
+
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &PermanentError{Err: err}
+}
+
+func (e *PermanentError) Error() string {
+	if e.Err == nil {
+		return "permanent error"
+	}
+
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+func (e *PermanentError) Permanent() bool {
+	return true
+}
+
+//////////////////////////////////////////////////
+
+type RetryableError struct {
+	Err error
+}
+
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &RetryableError{Err: err}
+}
+
+func (e *RetryableError) Error() string {
+	if e.Err == nil {
+		return "retryable error"
+	}
+
+	return e.Err.Error()
+}
+
+func (e *RetryableError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RetryableError) Temporary() bool {
+	return true
+}
+
+//////////////////////////////////////////////////
+
+type RetryAfterError struct {
+	Err   error
+	Delay time.Duration
+}
+
+func RetryAfter(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+
+	return &RetryAfterError{Err: err, Delay: delay}
+}
+
+func (e *RetryAfterError) Error() string {
+	if e.Err == nil {
+		return "retry after " + e.Delay.String()
+	}
+
+	return e.Err.Error()
+}
+
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+func (e *RetryAfterError) Temporary() bool {
+	return true
+}
+
+func (e *RetryAfterError) RetryAfter() (delay time.Duration, ok bool) {
+	return e.Delay, e.Delay > 0
+}
+
+//////////////////////////////////////////////////
+
+func IsPermanent(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, InvalidHandle) {
+		return true
+	}
+
+	var pe interface{ Permanent() bool }
+	if errors.As(err, &pe) && pe.Permanent() {
+		return true
+	}
+
+	return false
+}
+
+func IsRetryable(err error) bool {
+	if err == nil || IsPermanent(err) {
+		return false
+	}
+
+	if _, ok := RetryAfterDelay(err); ok {
+		return true
+	}
+
+	// NOTE: context errors report themselves as temporary, but a handler
+	// that keeps timing out should still count toward the attempt limit.
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var te interface{ Temporary() bool }
+	if errors.As(err, &te) && te.Temporary() {
+		return true
+	}
+
+	return false
+}
+
+func RetryAfterDelay(err error) (delay time.Duration, ok bool) {
+	if err == nil {
+		return
+	}
+
+	var re interface {
+		RetryAfter() (time.Duration, bool)
+	}
+	if errors.As(err, &re) {
+		return re.RetryAfter()
+	}
+
+	return
+}
+
+//////////////////////////////////////////////////
+
+type attemptSettlement struct {
+	attempt    int
+	failures   int
+	remove     bool
+	reason     RemovalReason
+	backoff    int
+	retryAfter time.Duration
+}
+
+func settleAttempt(err error, attempt int, failures int, maxAttempts int) (s attemptSettlement) {
+	if err == nil {
+		return
+	}
+
+	// NOTE: consecutive failures (including retryable ones, which do not
+	// count toward the attempt limit) drive the backoff.
+	s.failures = failures + 1
+	s.backoff = s.failures
+
+	switch {
+	case IsPermanent(err):
+		s.attempt = attempt + 1
+		s.remove = true
+		s.reason = RemovalReasonInvalid
+
+	case IsRetryable(err):
+		s.attempt = attempt
+		s.retryAfter, _ = RetryAfterDelay(err)
+
+	default:
+		s.attempt = attempt + 1
+		s.remove = maxAttempts > 0 && s.attempt >= maxAttempts
+		if s.remove {
+			s.reason = RemovalReasonExhausted
+		}
+	}
+
+	return
+}
+
+func (s attemptSettlement) delay(policy BackoffPolicy, minimumDelay time.Duration) time.Duration {
+	if s.retryAfter > 0 {
+		if s.retryAfter < minimumDelay {
+			return minimumDelay
+		}
+
+		return s.retryAfter
+	}
+
+	return retryDelay(policy, s.backoff, minimumDelay)
+}
+
+//////////////////////////////////////////////////
+
+func marshalError(err error) *string {
+	if err == nil {
+		return nil
+	}
+
+	msg := err.Error()
+	return &msg
+}
+
+func unmarshalError(msg *string) error {
+	if msg == nil {
+		return nil
+	}
+
+	return errors.New(*msg)
+}
@@ -0,0 +1,50 @@
+package crawly
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSettleAttemptRetryableBacksOffProgressively(t *testing.T) {
+	policy := ExponentialBackoff{Initial: time.Second}
+	err := Retryable(errors.New("unavailable"))
+
+	attempt, failures := 0, 0
+	var previous time.Duration
+
+	for i := 0; i < 4; i++ {
+		s := settleAttempt(err, attempt, failures, 3)
+		if s.remove {
+			t.Fatalf("retryable error removed the handle (failure %d)", i+1)
+		}
+		if s.attempt != 0 {
+			t.Fatalf("retryable error counted toward attempts: %d", s.attempt)
+		}
+
+		delay := s.delay(policy, 0)
+		if delay <= previous {
+			t.Fatalf("delay did not grow: %s after %s", delay, previous)
+		}
+
+		attempt, failures, previous = s.attempt, s.failures, delay
+	}
+
+	if s := settleAttempt(nil, attempt, failures, 3); s.failures != 0 {
+		t.Fatalf("success did not reset failures: %d", s.failures)
+	}
+}
+
+func TestSettleAttemptExhausts(t *testing.T) {
+	err := errors.New("boom")
+
+	s := settleAttempt(err, 1, 1, 2)
+	if !s.remove || s.reason != RemovalReasonExhausted {
+		t.Fatalf("expected exhausted removal, got %+v", s)
+	}
+
+	s = settleAttempt(Permanent(err), 0, 0, 0)
+	if !s.remove || s.reason != RemovalReasonInvalid {
+		t.Fatalf("expected invalid removal, got %+v", s)
+	}
+}
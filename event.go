@@ -0,0 +1,128 @@
+package crawly
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/rubpy/crawly/csync"
+)
+
+//////////////////////////////////////////////////
+
+var EventListenerCapacity = 64
+
+type Event struct {
+	Kind   EventKind     `json:"kind"`
+	Reason RemovalReason `json:"reason"`
+
+	Handle  Handle    `json:"handle"`
+	Attempt int       `json:"attempt"`
+	Err     error     `json:"err"`
+	Time    time.Time `json:"time"`
+
+	Change *DataChange `json:"change,omitempty"`
+}
+
+func (ev Event) MarshalJSON() ([]byte, error) {
+	h, err := marshalHandleJSON(ev.Handle)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(struct {
+		Kind   EventKind     `json:"kind"`
+		Reason RemovalReason `json:"reason"`
+
+		Handle  json.RawMessage `json:"handle"`
+		Attempt int             `json:"attempt"`
+		Err     *string         `json:"err"`
+		Time    time.Time       `json:"time"`
+
+		Change *DataChange `json:"change,omitempty"`
+	}{ev.Kind, ev.Reason, h, ev.Attempt, marshalError(ev.Err), ev.Time, ev.Change})
+}
+
+//////////////////////////////////////////////////
+
+type EventKind uint
+
+const (
+	EventNone EventKind = iota
+	EventEntityAdded
+	EventEntityUpdated
+	EventEntityRemoved
+	EventEntityFailed
+	EventOrderRejected
+	EventDataChanged
+)
+
+func (kind EventKind) String() string {
+	switch kind {
+	case EventEntityAdded:
+		return "entity:added"
+	case EventEntityUpdated:
+		return "entity:updated"
+	case EventEntityRemoved:
+		return "entity:removed"
+	case EventEntityFailed:
+		return "entity:failed"
+	case EventOrderRejected:
+		return "order:rejected"
+	case EventDataChanged:
+		return "entity:changed"
+	}
+
+	return "none"
+}
+
+//////////////////////////////////////////////////
+
+type RemovalReason uint
+
+const (
+	RemovalReasonNone RemovalReason = iota
+	RemovalReasonUntracked
+	RemovalReasonInvalid
+	RemovalReasonExhausted
+	RemovalReasonExpired
+)
+
+func (reason RemovalReason) String() string {
+	switch reason {
+	case RemovalReasonUntracked:
+		return "untracked"
+	case RemovalReasonInvalid:
+		return "invalid"
+	case RemovalReasonExhausted:
+		return "exhausted"
+	case RemovalReasonExpired:
+		return "expired"
+	}
+
+	return "none"
+}
+
+//////////////////////////////////////////////////
+
+func (cr *Crawler) Events() csync.Listener[Event] {
+	return cr.eventBroadcaster().Listen()
+}
+
+func (cr *Crawler) eventBroadcaster() csync.Broadcaster[Event] {
+	cr.eventsOnce.Do(func() {
+		cr.events = csync.NewBroadcaster[Event](EventListenerCapacity)
+	})
+
+	return cr.events
+}
+
+func (cr *Crawler) emit(ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	// NOTE: events are dropped for listeners that cannot keep up, so that
+	// a slow consumer never stalls a pass.
+	cr.eventBroadcaster().Send(context.Background(), ev, false)
+}
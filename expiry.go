@@ -0,0 +1,95 @@
+package crawly
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/rubpy/crawly/clog"
+)
+
+//////////////////////////////////////////////////
+
+func (cr *Crawler) Touch(ctx context.Context, handle Handle, ttl time.Duration) (ok bool, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	} else if err = ctx.Err(); err != nil {
+		return
+	}
+
+	if handle == nil || !handle.Valid() {
+		err = InvalidHandle
+		return
+	}
+
+	lock := cr.entityLock(handle)
+	lock.Lock()
+	defer lock.Unlock()
+
+	entity, ok := cr.entities.Load(handle)
+	if !ok {
+		return
+	}
+
+	if ttl <= 0 {
+		ttl = entity.MaxAge
+	}
+	if ttl <= 0 {
+		ok = false
+		return
+	}
+
+	entity.ExpiresAt = time.Now().Add(ttl)
+
+	cr.entities.Store(handle, entity)
+	cr.reschedule(entity)
+	cr.persistEntity(ctx, entity)
+
+	return
+}
+
+//////////////////////////////////////////////////
+
+func (cr *Crawler) sweepExpired(ctx context.Context, handles []Handle, now time.Time) (entities []Entity, expired map[Handle]TrackingResult) {
+	expired = make(map[Handle]TrackingResult)
+
+	for _, handle := range handles {
+		entity, ok := cr.entities.Load(handle)
+		if !ok {
+			cr.schedule.Remove(handle)
+			continue
+		}
+
+		if !entity.Expired(now) {
+			entities = append(entities, entity)
+			continue
+		}
+
+		var tr TrackingResult
+		cr.expireEntity(ctx, &entity, &tr)
+		cr.commitTrackingResult(ctx, &tr)
+
+		expired[handle] = tr
+	}
+
+	return
+}
+
+func (cr *Crawler) expireEntity(ctx context.Context, entity *Entity, result *TrackingResult) {
+	result.Entity.Value = *entity
+	result.Entity.Action = TrackingActionRemove
+	result.Entity.Reason = RemovalReasonExpired
+
+	lp := clog.Params{
+		Message: "expire:entity",
+		Level:   slog.LevelInfo,
+
+		Values: clog.ParamGroup{
+			"entity": clog.ParamGroup{
+				"handle":     entity.Handle,
+				"expires_at": entity.ExpiresAt,
+			},
+		},
+	}
+	cr.Log(ctx, lp)
+}
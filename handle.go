@@ -1,11 +1,23 @@
 package crawly
 
-import "errors"
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+)
 
 //////////////////////////////////////////////////
 
 var (
-	InvalidHandle = errors.New("invalid handle")
+	InvalidHandle  = errors.New("invalid handle")
+	NilHandleCodec = errors.New("handle codec is nil")
+
+	InvalidHandleKind           = errors.New("invalid handle kind")
+	UnknownHandleKind           = errors.New("unknown handle kind")
+	HandleKindAlreadyRegistered = errors.New("handle kind is already registered")
 )
 
 type Handle interface {
@@ -14,3 +26,214 @@ type Handle interface {
 
 	String() string
 }
+
+//////////////////////////////////////////////////
+
+const handleKindSeparator = ":"
+
+type HandleCodec struct {
+	Kind string
+	Type reflect.Type
+
+	Parse  func(text string) (Handle, error)
+	Format func(handle Handle) (string, error)
+}
+
+type handleRegistry struct {
+	lock  sync.RWMutex
+	kinds map[string]HandleCodec
+	types map[reflect.Type]string
+}
+
+var handleCodecs handleRegistry
+
+func RegisterHandle[H Handle](kind string, parse func(text string) (H, error), format func(handle H) (string, error)) error {
+	if parse == nil {
+		return NilHandleCodec
+	}
+
+	codec := HandleCodec{
+		Kind: kind,
+		Type: reflect.TypeOf((*H)(nil)).Elem(),
+
+		Parse: func(text string) (Handle, error) {
+			h, err := parse(text)
+			if err != nil {
+				return nil, err
+			}
+
+			return h, nil
+		},
+	}
+
+	if format != nil {
+		codec.Format = func(handle Handle) (string, error) {
+			h, ok := handle.(H)
+			if !ok {
+				return "", InvalidHandle
+			}
+
+			return format(h)
+		}
+	}
+
+	return RegisterHandleCodec(codec)
+}
+
+func RegisterHandleCodec(codec HandleCodec) error {
+	if codec.Kind == "" || strings.Contains(codec.Kind, handleKindSeparator) {
+		return InvalidHandleKind
+	}
+
+	if codec.Parse == nil {
+		return NilHandleCodec
+	}
+
+	if codec.Format == nil {
+		codec.Format = func(handle Handle) (string, error) {
+			return handle.String(), nil
+		}
+	}
+
+	handleCodecs.lock.Lock()
+	defer handleCodecs.lock.Unlock()
+
+	if handleCodecs.kinds == nil {
+		handleCodecs.kinds = make(map[string]HandleCodec)
+		handleCodecs.types = make(map[reflect.Type]string)
+	}
+
+	if _, ok := handleCodecs.kinds[codec.Kind]; ok {
+		return HandleKindAlreadyRegistered
+	}
+
+	handleCodecs.kinds[codec.Kind] = codec
+	if codec.Type != nil {
+		handleCodecs.types[codec.Type] = codec.Kind
+	}
+
+	return nil
+}
+
+func LookupHandleCodec(kind string) (codec HandleCodec, ok bool) {
+	handleCodecs.lock.RLock()
+	defer handleCodecs.lock.RUnlock()
+
+	codec, ok = handleCodecs.kinds[kind]
+	return
+}
+
+func handleCodecOf(handle Handle) (codec HandleCodec, ok bool) {
+	if handle == nil {
+		return
+	}
+
+	handleCodecs.lock.RLock()
+	defer handleCodecs.lock.RUnlock()
+
+	kind, ok := handleCodecs.types[reflect.TypeOf(handle)]
+	if !ok {
+		return
+	}
+
+	codec, ok = handleCodecs.kinds[kind]
+	return
+}
+
+//////////////////////////////////////////////////
+
+func MarshalHandle(handle Handle) (text string, err error) {
+	if handle == nil {
+		err = InvalidHandle
+		return
+	}
+
+	codec, ok := handleCodecOf(handle)
+	if !ok {
+		err = UnknownHandleKind
+		return
+	}
+
+	value, err := codec.Format(handle)
+	if err != nil {
+		return
+	}
+
+	text = codec.Kind + handleKindSeparator + value
+	return
+}
+
+func UnmarshalHandle(text string) (handle Handle, err error) {
+	kind, value, ok := strings.Cut(text, handleKindSeparator)
+	if !ok {
+		err = InvalidHandleKind
+		return
+	}
+
+	codec, ok := LookupHandleCodec(kind)
+	if !ok {
+		err = UnknownHandleKind
+		return
+	}
+
+	handle, err = codec.Parse(value)
+	if err != nil {
+		return
+	}
+
+	if handle == nil || !handle.Valid() {
+		handle, err = nil, InvalidHandle
+	}
+
+	return
+}
+
+//////////////////////////////////////////////////
+
+func HandleKind(handle Handle) string {
+	if handle == nil {
+		return ""
+	}
+
+	if kh, ok := handle.(interface{ Kind() string }); ok {
+		return kh.Kind()
+	}
+
+	if codec, ok := handleCodecOf(handle); ok {
+		return codec.Kind
+	}
+
+	return reflect.TypeOf(handle).String()
+}
+
+//////////////////////////////////////////////////
+
+func marshalHandleJSON(handle Handle) ([]byte, error) {
+	if handle == nil {
+		return []byte("null"), nil
+	}
+
+	// NOTE: unregistered handles are rejected (just like by Result), rather
+	// than encoded in a way that cannot be decoded back into a Handle.
+	text, err := MarshalHandle(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(text)
+}
+
+func unmarshalHandleJSON(data []byte) (handle Handle, err error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return
+	}
+
+	var text string
+	if err = json.Unmarshal(data, &text); err != nil {
+		err = UnknownHandleKind
+		return
+	}
+
+	return UnmarshalHandle(text)
+}
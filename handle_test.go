@@ -0,0 +1,83 @@
+package crawly
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+//////////////////////////////////////////////////
+
+func TestRegisterHandleNilCodec(t *testing.T) {
+	if err := RegisterHandle[testHandle]("nil-parse", nil, nil); !errors.Is(err, NilHandleCodec) {
+		t.Fatalf("RegisterHandle: expected NilHandleCodec, got %v", err)
+	}
+
+	if err := RegisterHandleCodec(HandleCodec{Kind: "nil-parse"}); !errors.Is(err, NilHandleCodec) {
+		t.Fatalf("RegisterHandleCodec: expected NilHandleCodec, got %v", err)
+	}
+}
+
+func TestResultJSONRoundTrip(t *testing.T) {
+	registerTestHandle(t)
+
+	r := Result{
+		Valid: true,
+		Pass:  3,
+
+		Entities: map[Handle]TrackingResult{
+			testHandle("a"): {},
+		},
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var decoded Result
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+
+	if _, ok := decoded.Entities[testHandle("a")]; !ok || decoded.Pass != 3 {
+		t.Fatalf("result did not round-trip: %+v", decoded)
+	}
+}
+
+func TestResultJSONUnregisteredHandle(t *testing.T) {
+	r := Result{
+		Entities: map[Handle]TrackingResult{
+			unregisteredHandle("a"): {},
+		},
+	}
+
+	if _, err := json.Marshal(r); !errors.Is(err, UnknownHandleKind) {
+		t.Fatalf("expected UnknownHandleKind, got %v", err)
+	}
+}
+
+func TestHandleJSONUnregisteredHandle(t *testing.T) {
+	registerTestHandle(t)
+
+	for name, v := range map[string]any{
+		"order":   Order{Handle: unregisteredHandle("a")},
+		"entity":  Entity{Handle: unregisteredHandle("a")},
+		"event":   Event{Handle: unregisteredHandle("a")},
+		"outcome": OrderOutcome{Handle: unregisteredHandle("a")},
+	} {
+		if _, err := json.Marshal(v); !errors.Is(err, UnknownHandleKind) {
+			t.Errorf("%s: expected UnknownHandleKind, got %v", name, err)
+		}
+	}
+
+	data, err := json.Marshal(Order{Handle: testHandle("a")})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var order Order
+	if err = json.Unmarshal(data, &order); err != nil || !order.Handle.Equal(testHandle("a")) {
+		t.Fatalf("order did not round-trip: err=%v handle=%v", err, order.Handle)
+	}
+}
@@ -0,0 +1,323 @@
+package crawly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rubpy/crawly/csync"
+)
+
+//////////////////////////////////////////////////
+
+var ManagerListenerCapacity = 64
+
+var (
+	NilCrawler               = errors.New("crawler is nil")
+	InvalidCrawlerName       = errors.New("invalid crawler name")
+	CrawlerAlreadyRegistered = errors.New("crawler is already registered")
+	ManagerAlreadyActive     = errors.New("manager is already active")
+)
+
+type Manager struct {
+	lock     sync.Mutex
+	crawlers map[string]*managedCrawler
+	active   bool
+	ctx      context.Context
+
+	// NOTE: starting and stopping crawlers (which may take a while, e.g. when
+	// draining) is serialized by transitionLock, so that lock is only ever
+	// held briefly and never blocks Status, Names or Lookup.
+	transitionLock sync.Mutex
+
+	results     csync.Broadcaster[ManagedResult]
+	resultsOnce sync.Once
+}
+
+type managedCrawler struct {
+	name     string
+	crawler  AnyCrawler
+	settings SessionSettings
+
+	listener csync.Listener[*Result]
+	lastPass csync.Value[time.Time]
+}
+
+type ManagedResult struct {
+	Name   string  `json:"name"`
+	Result *Result `json:"result"`
+}
+
+type CrawlerStatus struct {
+	Name     string             `json:"name"`
+	State    csync.SessionState `json:"state"`
+	Active   bool               `json:"active"`
+	Paused   bool               `json:"paused"`
+	Tracked  int                `json:"tracked"`
+	LastPass time.Time          `json:"last_pass"`
+}
+
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+//////////////////////////////////////////////////
+
+func (m *Manager) Register(name string, crawler AnyCrawler, settings SessionSettings) (err error) {
+	if name == "" {
+		return InvalidCrawlerName
+	}
+
+	if crawler == nil {
+		return NilCrawler
+	}
+
+	m.transitionLock.Lock()
+	defer m.transitionLock.Unlock()
+
+	m.lock.Lock()
+	_, registered := m.crawlers[name]
+	active, activeCtx := m.active, m.ctx
+	m.lock.Unlock()
+
+	if registered {
+		return CrawlerAlreadyRegistered
+	}
+
+	mc := &managedCrawler{
+		name:     name,
+		crawler:  crawler,
+		settings: settings,
+	}
+
+	// NOTE: crawlers registered while the manager is running are started
+	// right away (with the same context as the rest).
+	if active {
+		if err = m.start(activeCtx, mc); err != nil {
+			return
+		}
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.crawlers == nil {
+		m.crawlers = make(map[string]*managedCrawler)
+	}
+	m.crawlers[name] = mc
+
+	return
+}
+
+func (m *Manager) Unregister(ctx context.Context, name string) (ok bool, err error) {
+	m.transitionLock.Lock()
+	defer m.transitionLock.Unlock()
+
+	m.lock.Lock()
+	mc, ok := m.crawlers[name]
+	delete(m.crawlers, name)
+	m.lock.Unlock()
+
+	if !ok {
+		return
+	}
+
+	err = m.stop(ctx, mc)
+	return
+}
+
+func (m *Manager) Lookup(name string) (crawler AnyCrawler, ok bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	mc, ok := m.crawlers[name]
+	if !ok {
+		return
+	}
+
+	crawler = mc.crawler
+	return
+}
+
+func (m *Manager) Names() (names []string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	names = make([]string, 0, len(m.crawlers))
+	for name := range m.crawlers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return
+}
+
+//////////////////////////////////////////////////
+
+func (m *Manager) Active() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.active
+}
+
+func (m *Manager) Start(ctx context.Context) (err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	} else if err = ctx.Err(); err != nil {
+		return
+	}
+
+	m.transitionLock.Lock()
+	defer m.transitionLock.Unlock()
+
+	m.lock.Lock()
+	active, crawlers := m.active, m.sorted()
+	m.lock.Unlock()
+
+	if active {
+		return ManagerAlreadyActive
+	}
+
+	var started []*managedCrawler
+	for _, mc := range crawlers {
+		if err = m.start(ctx, mc); err != nil {
+			// NOTE: crawlers are started all-or-nothing.
+			for _, smc := range started {
+				m.stop(ctx, smc)
+			}
+
+			return
+		}
+
+		started = append(started, mc)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.active = true
+	m.ctx = ctx
+
+	return
+}
+
+func (m *Manager) Stop(ctx context.Context) (err error) {
+	m.transitionLock.Lock()
+	defer m.transitionLock.Unlock()
+
+	m.lock.Lock()
+	active, crawlers := m.active, m.sorted()
+	m.active = false
+	m.ctx = nil
+	m.lock.Unlock()
+
+	if !active {
+		return
+	}
+
+	var errs []error
+	for _, mc := range crawlers {
+		if err := m.stop(ctx, mc); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	err = errors.Join(errs...)
+	return
+}
+
+func (m *Manager) Listen() csync.Listener[ManagedResult] {
+	return m.resultBroadcaster().Listen()
+}
+
+func (m *Manager) Status() (statuses []CrawlerStatus) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	statuses = make([]CrawlerStatus, 0, len(m.crawlers))
+	for _, mc := range m.sorted() {
+		statuses = append(statuses, CrawlerStatus{
+			Name:     mc.name,
+			State:    mc.crawler.State(),
+			Active:   mc.crawler.Active(),
+			Paused:   mc.crawler.Paused(),
+			Tracked:  len(mc.crawler.Tracked()),
+			LastPass: mc.lastPass.Load(),
+		})
+	}
+
+	return
+}
+
+//////////////////////////////////////////////////
+
+func (m *Manager) sorted() (crawlers []*managedCrawler) {
+	crawlers = make([]*managedCrawler, 0, len(m.crawlers))
+	for _, mc := range m.crawlers {
+		crawlers = append(crawlers, mc)
+	}
+
+	sort.Slice(crawlers, func(i, j int) bool {
+		return crawlers[i].name < crawlers[j].name
+	})
+
+	return
+}
+
+func (m *Manager) start(ctx context.Context, mc *managedCrawler) (err error) {
+	// NOTE: the listener has to be set up before the session starts, so
+	// that not even the first pass is missed.
+	listener := mc.crawler.Listen()
+
+	if err = mc.crawler.Start(ctx, mc.settings); err != nil {
+		listener.Discard()
+
+		err = fmt.Errorf("%s: %w", mc.name, err)
+		return
+	}
+
+	mc.listener = listener
+	go m.forward(mc, listener)
+
+	return
+}
+
+func (m *Manager) stop(ctx context.Context, mc *managedCrawler) (err error) {
+	if mc.listener == nil {
+		return
+	}
+
+	if _, err = mc.crawler.Stop(ctx); err != nil {
+		err = fmt.Errorf("%s: %w", mc.name, err)
+	}
+
+	mc.listener.Discard()
+	mc.listener = nil
+
+	return
+}
+
+func (m *Manager) forward(mc *managedCrawler, listener csync.Listener[*Result]) {
+	for result := range listener.Channel() {
+		if result != nil {
+			mc.lastPass.Store(result.Timestamp)
+		}
+
+		m.resultBroadcaster().Send(context.Background(), ManagedResult{
+			Name:   mc.name,
+			Result: result,
+		}, false)
+	}
+}
+
+func (m *Manager) resultBroadcaster() csync.Broadcaster[ManagedResult] {
+	m.resultsOnce.Do(func() {
+		m.results = csync.NewBroadcaster[ManagedResult](ManagerListenerCapacity)
+	})
+
+	return m.results
+}
@@ -0,0 +1,187 @@
+package crawly
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rubpy/crawly/csync"
+)
+
+//////////////////////////////////////////////////
+
+func startTestManager(t *testing.T, m *Manager) {
+	t.Helper()
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := m.Stop(ctx); err != nil {
+			t.Errorf("Stop: %v", err)
+		}
+	})
+}
+
+func registerTestCrawler(t *testing.T, m *Manager, name string, settings SessionSettings) *Crawler {
+	t.Helper()
+
+	if settings.Interval == 0 {
+		settings.Interval = 20 * time.Millisecond
+	}
+
+	cr := newTestCrawler(t)
+	if err := m.Register(name, cr, settings); err != nil {
+		t.Fatalf("Register(%s): %v", name, err)
+	}
+
+	return cr
+}
+
+//////////////////////////////////////////////////
+
+func TestManagerMergesResults(t *testing.T) {
+	m := NewManager()
+	registerTestCrawler(t, m, "a", SessionSettings{})
+	registerTestCrawler(t, m, "b", SessionSettings{})
+
+	listener := m.Listen()
+	defer listener.Discard()
+
+	startTestManager(t, m)
+
+	seen := make(map[string]bool)
+	timeout := time.After(5 * time.Second)
+	for len(seen) < 2 {
+		select {
+		case mr := <-listener.Channel():
+			if mr.Result == nil {
+				t.Fatalf("%s: nil result", mr.Name)
+			}
+			seen[mr.Name] = true
+
+		case <-timeout:
+			t.Fatalf("timed out waiting for results, got %v", seen)
+		}
+	}
+}
+
+func TestManagerStartIsAllOrNothing(t *testing.T) {
+	m := NewManager()
+	a := registerTestCrawler(t, m, "a", SessionSettings{})
+	b := registerTestCrawler(t, m, "b", SessionSettings{})
+
+	// NOTE: a crawler that is already running cannot be started again.
+	startTestCrawler(t, b, SessionSettings{})
+
+	if err := m.Start(context.Background()); !errors.Is(err, csync.SessionAlreadyActive) {
+		t.Fatalf("expected SessionAlreadyActive, got %v", err)
+	}
+	if m.Active() {
+		t.Fatalf("manager is active after a failed start")
+	}
+	if a.Active() {
+		t.Fatalf("crawler was left running after a failed start")
+	}
+}
+
+func TestManagerRegisterWhileActive(t *testing.T) {
+	m := NewManager()
+	startTestManager(t, m)
+
+	cr := registerTestCrawler(t, m, "a", SessionSettings{})
+	if !cr.Active() {
+		t.Fatalf("crawler registered while active was not started")
+	}
+
+	if err := m.Register("a", newTestCrawler(t), SessionSettings{}); !errors.Is(err, CrawlerAlreadyRegistered) {
+		t.Fatalf("expected CrawlerAlreadyRegistered, got %v", err)
+	}
+
+	if ok, err := m.Unregister(context.Background(), "a"); !ok || err != nil {
+		t.Fatalf("Unregister: ok=%v err=%v", ok, err)
+	}
+	if cr.Active() {
+		t.Fatalf("unregistered crawler is still running")
+	}
+}
+
+func TestManagerStatus(t *testing.T) {
+	m := NewManager()
+	b := registerTestCrawler(t, m, "b", SessionSettings{})
+	registerTestCrawler(t, m, "a", SessionSettings{})
+
+	startTestManager(t, m)
+
+	if _, err := b.Track(context.Background(), testHandle("x")); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	waitFor(t, "the entity to be tracked", func() bool {
+		return b.IsTracked(testHandle("x"))
+	})
+
+	statuses := m.Status()
+	if len(statuses) != 2 || statuses[0].Name != "a" || statuses[1].Name != "b" {
+		t.Fatalf("unexpected statuses: %+v", statuses)
+	}
+	for _, status := range statuses {
+		if !status.Active || status.State != csync.SessionRunning {
+			t.Errorf("%s: crawler not running: %+v", status.Name, status)
+		}
+	}
+	if statuses[1].Tracked != 1 {
+		t.Errorf("expected 1 tracked entity, got %d", statuses[1].Tracked)
+	}
+}
+
+func TestManagerStopDoesNotBlockStatus(t *testing.T) {
+	m := NewManager()
+	cr := registerTestCrawler(t, m, "a", SessionSettings{DrainTimeout: 5 * time.Second})
+
+	entered, release := make(chan struct{}), make(chan struct{})
+	SetCrawlerHandlers(cr, CrawlerHandlers{
+		Order: func(ctx context.Context, order *Order, result *TrackingResult) error {
+			close(entered)
+			<-release
+			return nil
+		},
+	})
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := cr.Track(context.Background(), testHandle("a")); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	<-entered
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- m.Stop(context.Background())
+	}()
+
+	waitFor(t, "the crawler to start stopping", func() bool {
+		return cr.State() == csync.SessionStopping
+	})
+
+	statuses := make(chan []CrawlerStatus, 1)
+	go func() {
+		statuses <- m.Status()
+	}()
+
+	select {
+	case <-statuses:
+	case <-time.After(time.Second):
+		t.Fatalf("Status blocked while the manager was stopping")
+	}
+
+	close(release)
+	if err := <-stopped; err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+}
@@ -0,0 +1,209 @@
+package crawly
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/rubpy/crawly/clog"
+	"github.com/rubpy/crawly/csync"
+)
+
+//////////////////////////////////////////////////
+
+type OrderMiddleware func(next OrderHandler) OrderHandler
+type EntityMiddleware func(next EntityHandler) EntityHandler
+
+type Middleware struct {
+	Order  OrderMiddleware
+	Entity EntityMiddleware
+}
+
+//////////////////////////////////////////////////
+
+func (cr *Crawler) Use(middlewares ...Middleware) {
+	cr.middlewareLock.Lock()
+	defer cr.middlewareLock.Unlock()
+
+	current := cr.middlewares.Load()
+
+	mws := make([]Middleware, 0, len(current)+len(middlewares))
+	mws = append(mws, current...)
+	mws = append(mws, middlewares...)
+
+	cr.middlewares.Store(mws)
+}
+
+func (cr *Crawler) orderHandler(handlers CrawlerHandlers) (handler OrderHandler) {
+	if handler = handlers.Order; handler == nil {
+		return
+	}
+
+	mws := cr.middlewares.Load()
+	for i := len(mws) - 1; i >= 0; i-- {
+		if mws[i].Order != nil {
+			handler = mws[i].Order(handler)
+		}
+	}
+
+	return
+}
+
+func (cr *Crawler) entityHandler(handlers CrawlerHandlers) (handler EntityHandler) {
+	if handler = handlers.Entity; handler == nil {
+		return
+	}
+
+	mws := cr.middlewares.Load()
+	for i := len(mws) - 1; i >= 0; i-- {
+		if mws[i].Entity != nil {
+			handler = mws[i].Entity(handler)
+		}
+	}
+
+	return
+}
+
+//////////////////////////////////////////////////
+
+func DurationLogging(logger *slog.Logger, level slog.Level) Middleware {
+	log := func(ctx context.Context, message string, handle Handle, start time.Time, err error) {
+		lp := clog.Params{
+			Message: message,
+			Level:   level,
+			Err:     err,
+
+			Values: clog.ParamGroup{
+				"handle":   handle,
+				"duration": time.Since(start),
+			},
+		}
+		clog.WithParams(logger, ctx, lp)
+	}
+
+	return Middleware{
+		Order: func(next OrderHandler) OrderHandler {
+			return func(ctx context.Context, order *Order, result *TrackingResult) (err error) {
+				start := time.Now()
+				err = next(ctx, order, result)
+
+				log(ctx, "handler:order", order.Handle, start, err)
+				return
+			}
+		},
+
+		Entity: func(next EntityHandler) EntityHandler {
+			return func(ctx context.Context, entity *Entity, result *TrackingResult) (err error) {
+				start := time.Now()
+				err = next(ctx, entity, result)
+
+				log(ctx, "handler:entity", entity.Handle, start, err)
+				return
+			}
+		},
+	}
+}
+
+//////////////////////////////////////////////////
+
+var ExceededRateLimit = errors.New("exceeded rate limit")
+
+func RateLimit(interval time.Duration) Middleware {
+	limiter := &rateLimiter{interval: interval}
+
+	return Middleware{
+		Entity: func(next EntityHandler) EntityHandler {
+			return func(ctx context.Context, entity *Entity, result *TrackingResult) error {
+				if wait := limiter.wait(entity.Handle, time.Now()); wait > 0 {
+					return RetryAfter(ExceededRateLimit, wait)
+				}
+
+				return next(ctx, entity, result)
+			}
+		},
+	}
+}
+
+type rateLimiter struct {
+	interval time.Duration
+
+	last   csync.Map[Handle, time.Time]
+	pruned atomic.Int64
+}
+
+func (rl *rateLimiter) wait(handle Handle, now time.Time) time.Duration {
+	if rl.interval <= 0 {
+		return 0
+	}
+
+	rl.prune(now)
+
+	for {
+		prev, loaded := rl.last.LoadOrStore(handle, now)
+		if !loaded {
+			return 0
+		}
+
+		if elapsed := now.Sub(prev); elapsed < rl.interval {
+			return rl.interval - elapsed
+		}
+
+		if rl.last.CompareAndSwap(handle, prev, now) {
+			return 0
+		}
+	}
+}
+
+// NOTE: entries that are older than the interval no longer limit anything,
+// so (at most once per interval) they are dropped, lest handles that are no
+// longer tracked pile up.
+func (rl *rateLimiter) prune(now time.Time) {
+	pruned := rl.pruned.Load()
+	if now.UnixNano()-pruned < int64(rl.interval) || !rl.pruned.CompareAndSwap(pruned, now.UnixNano()) {
+		return
+	}
+
+	rl.last.Range(func(handle Handle, last time.Time) bool {
+		if now.Sub(last) >= rl.interval {
+			rl.last.CompareAndDelete(handle, last)
+		}
+
+		return true
+	})
+}
+
+//////////////////////////////////////////////////
+
+var ExceededHandlerTimeout = errors.New("exceeded handler timeout")
+
+func Timeout(timeout time.Duration) Middleware {
+	withTimeout := func(ctx context.Context) (context.Context, context.CancelFunc) {
+		if timeout <= 0 {
+			return context.WithCancel(ctx)
+		}
+
+		return context.WithTimeoutCause(ctx, timeout, ExceededHandlerTimeout)
+	}
+
+	return Middleware{
+		Order: func(next OrderHandler) OrderHandler {
+			return func(parentCtx context.Context, order *Order, result *TrackingResult) error {
+				ctx, cancel := withTimeout(parentCtx)
+				defer cancel()
+
+				return next(ctx, order, result)
+			}
+		},
+
+		Entity: func(next EntityHandler) EntityHandler {
+			return func(parentCtx context.Context, entity *Entity, result *TrackingResult) error {
+				ctx, cancel := withTimeout(parentCtx)
+				defer cancel()
+
+				return next(ctx, entity, result)
+			}
+		},
+	}
+}
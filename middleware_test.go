@@ -0,0 +1,156 @@
+package crawly
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////
+
+func recordingMiddleware(name string, calls *[]string) Middleware {
+	return Middleware{
+		Order: func(next OrderHandler) OrderHandler {
+			return func(ctx context.Context, order *Order, result *TrackingResult) error {
+				*calls = append(*calls, name)
+				return next(ctx, order, result)
+			}
+		},
+		Entity: func(next EntityHandler) EntityHandler {
+			return func(ctx context.Context, entity *Entity, result *TrackingResult) error {
+				*calls = append(*calls, name)
+				return next(ctx, entity, result)
+			}
+		},
+	}
+}
+
+func TestMiddlewareOrdering(t *testing.T) {
+	var calls []string
+
+	cr := &Crawler{}
+	cr.Use(recordingMiddleware("a", &calls), Middleware{})
+	cr.Use(recordingMiddleware("b", &calls))
+
+	handlers := CrawlerHandlers{
+		Order: func(ctx context.Context, order *Order, result *TrackingResult) error {
+			calls = append(calls, "handler")
+			return nil
+		},
+		Entity: func(ctx context.Context, entity *Entity, result *TrackingResult) error {
+			calls = append(calls, "handler")
+			return nil
+		},
+	}
+
+	cr.orderHandler(handlers)(context.Background(), &Order{}, &TrackingResult{})
+	cr.entityHandler(handlers)(context.Background(), &Entity{}, &TrackingResult{})
+
+	if got := strings.Join(calls, ","); got != "a,b,handler,a,b,handler" {
+		t.Fatalf("unexpected call order: %s", got)
+	}
+
+	if cr.orderHandler(CrawlerHandlers{}) != nil || cr.entityHandler(CrawlerHandlers{}) != nil {
+		t.Fatalf("middlewares wrapped a nil handler")
+	}
+}
+
+func TestDurationLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	boom := errors.New("boom")
+	mw := DurationLogging(logger, slog.LevelInfo)
+
+	err := mw.Entity(func(ctx context.Context, entity *Entity, result *TrackingResult) error {
+		return boom
+	})(context.Background(), &Entity{Handle: testHandle("a")}, &TrackingResult{})
+	if !errors.Is(err, boom) {
+		t.Fatalf("handler error not passed through: %v", err)
+	}
+
+	out := buf.String()
+	for _, s := range []string{"handler:entity", "handle=a", "duration=", "boom"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("log line is missing %q: %s", s, out)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	interval := time.Hour
+	handler := RateLimit(interval).Entity(func(ctx context.Context, entity *Entity, result *TrackingResult) error {
+		return nil
+	})
+
+	call := func(handle Handle) error {
+		return handler(context.Background(), &Entity{Handle: handle}, &TrackingResult{})
+	}
+
+	if err := call(testHandle("a")); err != nil {
+		t.Fatalf("first call was limited: %v", err)
+	}
+	if err := call(testHandle("b")); err != nil {
+		t.Fatalf("another handle was limited: %v", err)
+	}
+
+	err := call(testHandle("a"))
+	if !errors.Is(err, ExceededRateLimit) {
+		t.Fatalf("expected ExceededRateLimit, got %v", err)
+	}
+	if delay, ok := RetryAfterDelay(err); !ok || delay <= 0 || delay > interval {
+		t.Fatalf("unexpected retry-after delay: ok=%v delay=%v", ok, delay)
+	}
+}
+
+func TestRateLimiterPrunes(t *testing.T) {
+	rl := &rateLimiter{interval: time.Second}
+	now := time.Now()
+
+	for _, h := range []testHandle{"a", "b", "c"} {
+		if wait := rl.wait(h, now); wait != 0 {
+			t.Fatalf("%s: first call was limited", h)
+		}
+	}
+	if wait := rl.wait(testHandle("a"), now.Add(500*time.Millisecond)); wait != 500*time.Millisecond {
+		t.Fatalf("expected to wait 500ms, got %v", wait)
+	}
+
+	rl.wait(testHandle("d"), now.Add(2*time.Second))
+
+	entries := 0
+	rl.last.Range(func(handle Handle, last time.Time) bool {
+		entries++
+		return true
+	})
+	if entries != 1 {
+		t.Fatalf("expected stale entries to be pruned, %d left", entries)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	mw := Timeout(10 * time.Millisecond)
+
+	err := mw.Order(func(ctx context.Context, order *Order, result *TrackingResult) error {
+		<-ctx.Done()
+		return context.Cause(ctx)
+	})(context.Background(), &Order{}, &TrackingResult{})
+	if !errors.Is(err, ExceededHandlerTimeout) {
+		t.Fatalf("expected ExceededHandlerTimeout, got %v", err)
+	}
+
+	err = Timeout(0).Entity(func(ctx context.Context, entity *Entity, result *TrackingResult) error {
+		if _, ok := ctx.Deadline(); ok {
+			return errors.New("unexpected deadline")
+		}
+
+		return nil
+	})(context.Background(), &Entity{}, &TrackingResult{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
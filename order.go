@@ -2,6 +2,7 @@ package crawly
 
 import (
 	"context"
+	"encoding/json"
 	"log/slog"
 	"time"
 
@@ -12,14 +13,129 @@ import (
 
 type Order struct {
 	Command        TrackingCommand `json:"command"`
+	Sequence       uint64          `json:"sequence"`
 	Attempt        int             `json:"attempt"`
+	Failures       int             `json:"failures"`
 	LastProcessing time.Time       `json:"last_processing"`
+	NextRun        time.Time       `json:"next_run"`
 
-	Handle Handle `json:"handle"`
-	Data   any    `json:"data"`
+	Handle   Handle            `json:"handle"`
+	Data     any               `json:"data"`
+	Settings *SettingsOverride `json:"settings,omitempty"`
+
+	Tags     []string      `json:"tags,omitempty"`
+	Priority int           `json:"priority,omitempty"`
+	TTL      time.Duration `json:"ttl,omitempty"`
+	Callback OrderCallback `json:"-"`
+
+	SuspendedUntil time.Time `json:"suspended_until"`
+
+	// NOTE: modify orders (refresh, suspend, unsuspend and update) that are
+	// issued for the same handle before the pending one gets processed are
+	// merged into it; these are all of the commands, in order.
+	Commands []TrackingCommand `json:"commands,omitempty"`
 }
 
 type OrderHandler func(ctx context.Context, order *Order, result *TrackingResult) error
+type OrderCallback func(result TrackingResult)
+
+func (o Order) MarshalJSON() ([]byte, error) {
+	type order Order
+
+	h, err := marshalHandleJSON(o.Handle)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(struct {
+		order
+		Handle json.RawMessage `json:"handle"`
+	}{order(o), h})
+}
+
+func (o *Order) UnmarshalJSON(data []byte) (err error) {
+	type order Order
+
+	aux := struct {
+		*order
+		Handle json.RawMessage `json:"handle"`
+	}{order: (*order)(o)}
+	if err = json.Unmarshal(data, &aux); err != nil {
+		return
+	}
+
+	o.Handle, err = unmarshalHandleJSON(aux.Handle)
+	return
+}
+
+func (o *Order) initEntity(entity *Entity) {
+	if entity.Data == nil {
+		entity.Data = o.Data
+	}
+
+	if len(o.Tags) > 0 {
+		entity.Tags = append([]string(nil), o.Tags...)
+	}
+
+	if o.TTL > 0 {
+		entity.MaxAge = o.TTL
+	}
+	if entity.MaxAge > 0 && entity.ExpiresAt.IsZero() {
+		entity.ExpiresAt = time.Now().Add(entity.MaxAge)
+	}
+}
+
+func (o *Order) commands() []TrackingCommand {
+	if len(o.Commands) == 0 {
+		return []TrackingCommand{o.Command}
+	}
+
+	return o.Commands
+}
+
+func (o *Order) modifies(command TrackingCommand) bool {
+	for _, c := range o.commands() {
+		if c == command {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o *Order) merge(next Order) (merged Order) {
+	merged = *o
+	merged.Command = next.Command
+	merged.Commands = append(append([]TrackingCommand(nil), o.commands()...), next.Command)
+	merged.Callback = chainOrderCallbacks(o.Callback, next.Callback)
+
+	switch next.Command {
+	case TrackingCommandSuspend:
+		merged.SuspendedUntil = next.SuspendedUntil
+	case TrackingCommandUpdate:
+		merged.Data = next.Data
+	}
+
+	return
+}
+
+func (o *Order) modifyEntity(entity *Entity) {
+	for _, command := range o.commands() {
+		switch command {
+		case TrackingCommandRefresh:
+			entity.NextRun = time.Now()
+		case TrackingCommandSuspend:
+			entity.Suspended = true
+			entity.SuspendedUntil = o.SuspendedUntil
+		case TrackingCommandUnsuspend:
+			entity.Suspended = false
+			entity.SuspendedUntil = time.Time{}
+			entity.Panics = 0
+		case TrackingCommandUpdate:
+			entity.Data = o.Data
+		}
+	}
+}
 
 //////////////////////////////////////////////////
 
@@ -30,11 +146,13 @@ func (cr *Crawler) processOrder(parentCtx context.Context, order *Order, result
 
 	result.Order.Value = *order
 	result.Entity.Value.Handle = order.Handle
+	result.Entity.Value.Settings = order.Settings
+	result.Entity.Value.Priority = order.Priority
 
 	var ctx context.Context
 	var cancel context.CancelFunc
 
-	settings := cr.loadSettings()
+	settings := cr.resolveSettings(order.Handle, order.Settings)
 
 	timeout := settings.TrackingOrderTimeout
 	if timeout > 0 {
@@ -44,7 +162,11 @@ func (cr *Crawler) processOrder(parentCtx context.Context, order *Order, result
 	}
 	defer cancel()
 
-	if !result.Order.Value.LastProcessing.IsZero() {
+	if !result.Order.Value.NextRun.IsZero() {
+		if time.Now().Before(result.Order.Value.NextRun) {
+			return
+		}
+	} else if !result.Order.Value.LastProcessing.IsZero() {
 		elapsed := time.Now().Sub(result.Order.Value.LastProcessing)
 
 		if elapsed < settings.MinimumTrackingOrderDelay {
@@ -57,27 +179,28 @@ func (cr *Crawler) processOrder(parentCtx context.Context, order *Order, result
 		maxAttempts = -1
 	}
 
+	var settlement attemptSettlement
+
 	switch result.Order.Value.Command {
 	case TrackingCommandStart:
 		{
-			handlers := cr.loadHandlers()
-			if handlers.Order != nil {
-				result.Order.Err = handlers.Order(ctx, &result.Order.Value, result)
+			handler := cr.orderHandler(cr.loadHandlers())
+			if handler != nil {
+				result.Order.Err = cr.callOrderHandler(ctx, handler, &result.Order.Value, result)
 			} else {
 				result.Order.Err = NilHandler
 			}
 
-			if result.Order.Err != nil {
-				result.Order.Value.Attempt++
-			} else {
-				result.Order.Value.Attempt = 0
-			}
+			settlement = settleAttempt(result.Order.Err, result.Order.Value.Attempt, result.Order.Value.Failures, maxAttempts)
+			result.Order.Value.Attempt = settlement.attempt
+			result.Order.Value.Failures = settlement.failures
 
 			if result.Order.Err != nil {
 				result.Entity.Action = TrackingActionNone
 
-				if result.Order.Err == InvalidHandle || (maxAttempts > 0 && result.Order.Value.Attempt >= maxAttempts) {
+				if settlement.remove {
 					result.Order.Action = TrackingActionRemove
+					result.Order.Reason = settlement.reason
 					result.Entity.Action = TrackingActionNone
 				} else if result.Order.Action == TrackingActionNone {
 					result.Order.Action = TrackingActionUpdate
@@ -87,19 +210,52 @@ func (cr *Crawler) processOrder(parentCtx context.Context, order *Order, result
 					result.Order.Action = TrackingActionRemove
 					result.Entity.Action = TrackingActionUpdate
 				}
+
+				if result.Entity.Action == TrackingActionUpdate {
+					result.Order.Value.initEntity(&result.Entity.Value)
+				}
 			}
 		}
 
 	case TrackingCommandStop:
 		result.Order.Action = TrackingActionRemove
 		result.Entity.Action = TrackingActionRemove
+		result.Entity.Reason = RemovalReasonUntracked
+
+	case TrackingCommandRefresh, TrackingCommandSuspend, TrackingCommandUnsuspend, TrackingCommandUpdate:
+		result.Order.Action = TrackingActionRemove
+
+		entity, ok := cr.entities.Load(result.Order.Value.Handle)
+		if !ok {
+			result.Order.Err = UntrackedHandle
+			result.Order.Reason = RemovalReasonInvalid
+			break
+		}
+
+		previous := entity.Data
+		result.Order.Value.modifyEntity(&entity)
+
+		if result.Order.Value.modifies(TrackingCommandUpdate) &&
+			cr.callChangeDetector(ctx, cr.loadHandlers().ChangeDetector, entity.Handle, previous, entity.Data) {
+			result.Change = &DataChange{
+				Previous: previous,
+				Current:  entity.Data,
+			}
+		}
+
+		result.Entity.Value = entity
+		result.Entity.Action = TrackingActionUpdate
 
 	default:
 		result.Order.Err = InvalidTrackingCommand
 		result.Order.Action = TrackingActionRemove
+		result.Order.Reason = RemovalReasonInvalid
 	}
 
 	result.Order.Value.LastProcessing = time.Now()
+	result.Order.Value.NextRun = result.Order.Value.LastProcessing.Add(
+		settlement.delay(settings.TrackingOrderBackoff, settings.MinimumTrackingOrderDelay),
+	)
 
 	{
 		lp := clog.Params{
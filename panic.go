@@ -0,0 +1,93 @@
+package crawly
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/rubpy/crawly/clog"
+)
+
+//////////////////////////////////////////////////
+
+type HandlerPanic struct {
+	Value any    `json:"value"`
+	Stack []byte `json:"stack"`
+}
+
+func (e *HandlerPanic) Error() string {
+	return fmt.Sprintf("handler panic: %v", e.Value)
+}
+
+func IsHandlerPanic(err error) bool {
+	var hp *HandlerPanic
+	return errors.As(err, &hp)
+}
+
+//////////////////////////////////////////////////
+
+func (cr *Crawler) callOrderHandler(ctx context.Context, handler OrderHandler, order *Order, result *TrackingResult) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = cr.recoverHandlerPanic(ctx, "panic:order", order.Handle, v)
+		}
+	}()
+
+	return handler(ctx, order, result)
+}
+
+func (cr *Crawler) callEntityHandler(ctx context.Context, handler EntityHandler, entity *Entity, result *TrackingResult) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = cr.recoverHandlerPanic(ctx, "panic:entity", entity.Handle, v)
+		}
+	}()
+
+	return handler(ctx, entity, result)
+}
+
+func (cr *Crawler) callOrderCallback(ctx context.Context, callback OrderCallback, result TrackingResult) {
+	defer func() {
+		if v := recover(); v != nil {
+			cr.recoverHandlerPanic(ctx, "panic:callback", result.Order.Value.Handle, v)
+		}
+	}()
+
+	callback(result)
+}
+
+func (cr *Crawler) callChangeDetector(ctx context.Context, detector ChangeDetector, handle Handle, previous any, current any) (changed bool) {
+	// NOTE: a panicking detector (or DataEqualer) is treated as if nothing
+	// had changed.
+	defer func() {
+		if v := recover(); v != nil {
+			cr.recoverHandlerPanic(ctx, "panic:change", handle, v)
+			changed = false
+		}
+	}()
+
+	return detectChange(detector, handle, previous, current)
+}
+
+func (cr *Crawler) recoverHandlerPanic(ctx context.Context, message string, handle Handle, v any) error {
+	hp := &HandlerPanic{
+		Value: v,
+		Stack: debug.Stack(),
+	}
+
+	lp := clog.Params{
+		Message: message,
+		Level:   slog.LevelError,
+		Err:     hp,
+
+		Values: clog.ParamGroup{
+			"handle": handle,
+			"stack":  string(hp.Stack),
+		},
+	}
+	cr.Log(ctx, lp)
+
+	return hp
+}
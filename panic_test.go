@@ -0,0 +1,103 @@
+package crawly
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////
+
+func TestIsHandlerPanicWrapped(t *testing.T) {
+	err := fmt.Errorf("middleware: %w", &HandlerPanic{Value: "boom"})
+
+	if !IsHandlerPanic(err) {
+		t.Fatalf("wrapped handler panic was not recognized")
+	}
+	if IsHandlerPanic(fmt.Errorf("plain")) {
+		t.Fatalf("plain error was recognized as a handler panic")
+	}
+}
+
+func TestProcessEntityQuarantineSuspends(t *testing.T) {
+	cr := &Crawler{}
+	SetCrawlerSettings(cr, CrawlerSettings{MaximumTrackingPanics: 2})
+	SetCrawlerHandlers(cr, CrawlerHandlers{
+		Entity: func(ctx context.Context, entity *Entity, result *TrackingResult) error {
+			panic("boom")
+		},
+	})
+
+	entity := Entity{Handle: testHandle("a")}
+
+	for i := 1; i <= 2; i++ {
+		var tr TrackingResult
+		if err := cr.processEntity(context.Background(), &entity, &tr); err != nil {
+			t.Fatalf("processEntity: %v", err)
+		}
+
+		if !IsHandlerPanic(tr.Entity.Err) {
+			t.Fatalf("expected a handler panic, got %v", tr.Entity.Err)
+		}
+		if tr.Entity.Action != TrackingActionUpdate {
+			t.Fatalf("quarantined entity should be kept, got action %v", tr.Entity.Action)
+		}
+
+		entity = tr.Entity.Value
+		entity.NextRun = entity.LastProcessing
+	}
+
+	if !entity.Suspended || !entity.SuspendedUntil.IsZero() {
+		t.Fatalf("expected the entity to be suspended indefinitely: %+v", entity)
+	}
+}
+
+func TestChangeDetectorPanic(t *testing.T) {
+	cr := &Crawler{}
+	SetCrawlerHandlers(cr, CrawlerHandlers{
+		Entity: func(ctx context.Context, entity *Entity, result *TrackingResult) error {
+			entity.Data = "current"
+			return nil
+		},
+		ChangeDetector: func(handle Handle, previous any, current any) bool {
+			panic("boom")
+		},
+	})
+
+	entity := Entity{Handle: testHandle("a"), Data: "previous"}
+
+	var tr TrackingResult
+	if err := cr.processEntity(context.Background(), &entity, &tr); err != nil {
+		t.Fatalf("processEntity: %v", err)
+	}
+
+	if tr.Entity.Err != nil || tr.Change != nil {
+		t.Fatalf("expected no error and no change, got %v %+v", tr.Entity.Err, tr.Change)
+	}
+}
+
+func TestOrderCallbackPanic(t *testing.T) {
+	cr := newTestCrawler(t)
+
+	called := make(chan struct{})
+	_, err := cr.Track(context.Background(), testHandle("a"), WithCallback(func(result TrackingResult) {
+		close(called)
+		panic("boom")
+	}))
+	if err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+
+	startTestCrawler(t, cr, SessionSettings{})
+
+	select {
+	case <-called:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the callback")
+	}
+
+	waitFor(t, "the entity to be tracked", func() bool {
+		return cr.IsTracked(testHandle("a"))
+	})
+}
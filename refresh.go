@@ -0,0 +1,80 @@
+package crawly
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+//////////////////////////////////////////////////
+
+var BusyHandle = errors.New("handle is already being processed")
+
+func (cr *Crawler) Refresh(ctx context.Context, handle Handle) (result TrackingResult, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	} else if err = ctx.Err(); err != nil {
+		return
+	}
+
+	if handle == nil || !handle.Valid() {
+		err = InvalidHandle
+		return
+	}
+
+	err = cr.processExclusively(ctx, handle, true, &result)
+	return
+}
+
+//////////////////////////////////////////////////
+
+func (cr *Crawler) processExclusively(ctx context.Context, handle Handle, refresh bool, result *TrackingResult) (err error) {
+	if _, busy := cr.busy.LoadOrStore(handle, struct{}{}); busy {
+		err = BusyHandle
+		return
+	}
+	defer cr.busy.Delete(handle)
+
+	if err = cr.entitySlots.Acquire(ctx, cr.loadSettings().EntityConcurrency); err != nil {
+		return
+	}
+	defer cr.entitySlots.Release()
+
+	// NOTE: the entity is (re)loaded only once the handle is held, since it
+	// might have been processed by someone else in the meantime.
+	entity, ok := cr.entities.Load(handle)
+	if !ok {
+		err = UntrackedHandle
+		return
+	}
+
+	if refresh {
+		entity.NextRun = time.Now()
+	}
+
+	if err = cr.processEntity(ctx, &entity, result); err != nil {
+		return
+	}
+
+	cr.commitTrackingResult(ctx, result)
+	return
+}
+
+func (cr *Crawler) processOrderExclusively(ctx context.Context, order *Order, result *TrackingResult) (processed bool, err error) {
+	// NOTE: an order for a handle that is being processed (e.g., refreshed
+	// out of band) is postponed until the next pass, as its changes would
+	// otherwise be overwritten once the processing gets committed.
+	if _, busy := cr.busy.LoadOrStore(order.Handle, struct{}{}); busy {
+		return
+	}
+	defer cr.busy.Delete(order.Handle)
+
+	if err = cr.processOrder(ctx, order, result); err != nil {
+		return
+	}
+
+	cr.commitTrackingResult(ctx, result)
+
+	processed = true
+	return
+}
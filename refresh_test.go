@@ -0,0 +1,175 @@
+package crawly
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////
+
+type blockingRefresh struct {
+	block   atomic.Bool
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newBlockingRefreshCrawler(t *testing.T) (cr *Crawler, br *blockingRefresh) {
+	t.Helper()
+
+	br = &blockingRefresh{
+		entered: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+
+	cr = newTestCrawler(t)
+	handlers := LoadCrawlerHandlers(cr)
+	handlers.Entity = func(ctx context.Context, entity *Entity, result *TrackingResult) error {
+		if br.block.CompareAndSwap(true, false) {
+			close(br.entered)
+			<-br.release
+		}
+
+		return nil
+	}
+	SetCrawlerHandlers(cr, handlers)
+
+	return
+}
+
+func (br *blockingRefresh) refresh(t *testing.T, cr *Crawler, handle Handle) (done chan struct{}) {
+	t.Helper()
+
+	br.block.Store(true)
+
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+
+		if _, err := cr.Refresh(context.Background(), handle); err != nil {
+			t.Errorf("Refresh: %v", err)
+		}
+	}()
+
+	select {
+	case <-br.entered:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for the refresh to start")
+	}
+
+	return
+}
+
+func waitForPass(t *testing.T, cr *Crawler) {
+	t.Helper()
+
+	listener := cr.Listen()
+	defer listener.Discard()
+
+	if _, err := cr.Immediate(context.Background(), 0); err != nil {
+		t.Fatalf("Immediate: %v", err)
+	}
+
+	select {
+	case <-listener.Channel():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for a pass")
+	}
+}
+
+//////////////////////////////////////////////////
+
+func TestRefreshDoesNotResurrectUntrackedEntity(t *testing.T) {
+	cr, br := newBlockingRefreshCrawler(t)
+	ctx := context.Background()
+	a := testHandle("a")
+
+	events := cr.Events()
+	defer events.Discard()
+
+	if _, err := cr.Track(ctx, a); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	startTestCrawler(t, cr, SessionSettings{Interval: time.Hour})
+	waitFor(t, "the entity to be tracked", func() bool { return cr.IsTracked(a) })
+
+	done := br.refresh(t, cr, a)
+
+	if _, err := cr.Untrack(ctx, a); err != nil {
+		t.Fatalf("Untrack: %v", err)
+	}
+	waitForPass(t, cr)
+
+	close(br.release)
+	<-done
+
+	waitForPass(t, cr)
+	waitForPass(t, cr)
+
+	if cr.IsTracked(a) {
+		t.Fatalf("untracked entity was brought back by the refresh")
+	}
+
+	added := 0
+	for {
+		select {
+		case ev := <-events.Channel():
+			if ev.Kind == EventEntityAdded {
+				added++
+			}
+			continue
+		default:
+		}
+
+		break
+	}
+	if added != 1 {
+		t.Fatalf("expected 1 entity:added event, got %d", added)
+	}
+}
+
+func TestRefreshKeepsConcurrentSuspension(t *testing.T) {
+	cr, br := newBlockingRefreshCrawler(t)
+	ctx := context.Background()
+	a := testHandle("a")
+
+	if _, err := cr.Track(ctx, a); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	startTestCrawler(t, cr, SessionSettings{Interval: time.Hour})
+	waitFor(t, "the entity to be tracked", func() bool { return cr.IsTracked(a) })
+
+	done := br.refresh(t, cr, a)
+
+	if _, err := cr.Suspend(ctx, a); err != nil {
+		t.Fatalf("Suspend: %v", err)
+	}
+	waitForPass(t, cr)
+
+	close(br.release)
+	<-done
+
+	waitForPass(t, cr)
+
+	entity, ok := cr.entities.Load(a)
+	if !ok || !entity.Suspended {
+		t.Fatalf("suspension was lost: ok=%v %+v", ok, entity)
+	}
+}
+
+func TestCommitEntitySkipsRemovedEntity(t *testing.T) {
+	cr := &Crawler{}
+	a := testHandle("a")
+
+	cr.commitEntity(context.Background(), &TrackingResult{
+		Entity: actionableResult[Entity]{
+			Action: TrackingActionUpdate,
+			Value:  Entity{Handle: a},
+		},
+	})
+
+	if cr.IsTracked(a) {
+		t.Fatalf("entity processing added an untracked entity")
+	}
+}
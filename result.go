@@ -1,6 +1,9 @@
 package crawly
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 //////////////////////////////////////////////////
 
@@ -16,3 +19,111 @@ type Result struct {
 	Orders   map[Handle]TrackingResult `json:"orders"`
 	Entities map[Handle]TrackingResult `json:"entities"`
 }
+
+type resultJSON struct {
+	Err   *string `json:"err"`
+	Valid bool    `json:"valid"`
+	Idle  bool    `json:"idle"`
+
+	SessionID string    `json:"session_id"`
+	Pass      uint64    `json:"pass"`
+	Timestamp time.Time `json:"timestamp"`
+
+	Orders   map[string]TrackingResult `json:"orders"`
+	Entities map[string]TrackingResult `json:"entities"`
+}
+
+//////////////////////////////////////////////////
+
+func (r *Result) IsValid() bool {
+	return r != nil && r.Valid
+}
+
+func (r *Result) IsIdle() bool {
+	return r != nil && r.Idle
+}
+
+func (r Result) MarshalJSON() (data []byte, err error) {
+	aux := resultJSON{
+		Err:   marshalError(r.Err),
+		Valid: r.Valid,
+		Idle:  r.Idle,
+
+		SessionID: r.SessionID,
+		Pass:      r.Pass,
+		Timestamp: r.Timestamp,
+	}
+
+	if aux.Orders, err = marshalTrackingResults(r.Orders); err != nil {
+		return
+	}
+	if aux.Entities, err = marshalTrackingResults(r.Entities); err != nil {
+		return
+	}
+
+	return json.Marshal(aux)
+}
+
+func (r *Result) UnmarshalJSON(data []byte) (err error) {
+	var aux resultJSON
+	if err = json.Unmarshal(data, &aux); err != nil {
+		return
+	}
+
+	*r = Result{
+		Err:   unmarshalError(aux.Err),
+		Valid: aux.Valid,
+		Idle:  aux.Idle,
+
+		SessionID: aux.SessionID,
+		Pass:      aux.Pass,
+		Timestamp: aux.Timestamp,
+	}
+
+	if r.Orders, err = unmarshalTrackingResults(aux.Orders); err != nil {
+		return
+	}
+	if r.Entities, err = unmarshalTrackingResults(aux.Entities); err != nil {
+		return
+	}
+
+	return
+}
+
+// NOTE: results are keyed by their marshaled handles, so that they can be
+// decoded back; unregistered handle kinds are rejected.
+func marshalTrackingResults(m map[Handle]TrackingResult) (out map[string]TrackingResult, err error) {
+	if m == nil {
+		return
+	}
+
+	out = make(map[string]TrackingResult, len(m))
+	for handle, tr := range m {
+		var text string
+		if text, err = MarshalHandle(handle); err != nil {
+			return nil, err
+		}
+
+		out[text] = tr
+	}
+
+	return
+}
+
+func unmarshalTrackingResults(m map[string]TrackingResult) (out map[Handle]TrackingResult, err error) {
+	if m == nil {
+		return
+	}
+
+	out = make(map[Handle]TrackingResult, len(m))
+	for text, tr := range m {
+		var handle Handle
+		if handle, err = UnmarshalHandle(text); err != nil {
+			return nil, err
+		}
+
+		out[handle] = tr
+	}
+
+	return
+}
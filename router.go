@@ -0,0 +1,89 @@
+package crawly
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rubpy/crawly/csync"
+)
+
+//////////////////////////////////////////////////
+
+var UnroutedHandle = errors.New("no route for handle kind")
+
+type Router struct {
+	routes   csync.Map[string, CrawlerHandlers]
+	fallback csync.Value[CrawlerHandlers]
+}
+
+func NewRouter() *Router {
+	return &Router{}
+}
+
+//////////////////////////////////////////////////
+
+func (r *Router) Route(kind string, handlers CrawlerHandlers) {
+	r.routes.Store(kind, handlers)
+}
+
+func (r *Router) Unroute(kind string) {
+	r.routes.Delete(kind)
+}
+
+func (r *Router) Fallback(handlers CrawlerHandlers) {
+	r.fallback.Store(handlers)
+}
+
+func (r *Router) Kinds() (kinds []string) {
+	kinds = []string{}
+
+	r.routes.Range(func(kind string, _ CrawlerHandlers) bool {
+		kinds = append(kinds, kind)
+
+		return true
+	})
+
+	return
+}
+
+func (r *Router) Lookup(handle Handle) (handlers CrawlerHandlers, ok bool) {
+	if handlers, ok = r.routes.Load(HandleKind(handle)); ok {
+		return
+	}
+
+	handlers = r.fallback.Load()
+	ok = handlers.Order != nil || handlers.Entity != nil
+
+	return
+}
+
+func (r *Router) Handlers() CrawlerHandlers {
+	return CrawlerHandlers{
+		Order: func(ctx context.Context, order *Order, result *TrackingResult) error {
+			handlers, _ := r.Lookup(order.Handle)
+			if handlers.Order == nil {
+				return Permanent(UnroutedHandle)
+			}
+
+			return handlers.Order(ctx, order, result)
+		},
+
+		Entity: func(ctx context.Context, entity *Entity, result *TrackingResult) error {
+			handlers, _ := r.Lookup(entity.Handle)
+			if handlers.Entity == nil {
+				return Permanent(UnroutedHandle)
+			}
+
+			return handlers.Entity(ctx, entity, result)
+		},
+
+		ChangeDetector: func(handle Handle, previous any, current any) bool {
+			handlers, _ := r.Lookup(handle)
+			if handlers.ChangeDetector == nil {
+				return detectChange(nil, handle, previous, current)
+			}
+
+			return handlers.ChangeDetector(handle, previous, current)
+		},
+	}
+}
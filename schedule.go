@@ -0,0 +1,226 @@
+package crawly
+
+import (
+	"container/heap"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rubpy/crawly/csync"
+)
+
+//////////////////////////////////////////////////
+
+var (
+	MinimumWakeDelay = 1 * time.Second
+)
+
+type scheduler struct {
+	lock  sync.Mutex
+	items scheduleHeap
+	index map[Handle]*scheduleItem
+
+	wakeLock  sync.Mutex
+	wakeTimer *time.Timer
+}
+
+type scheduleItem struct {
+	handle   Handle
+	due      time.Time
+	priority int
+
+	position int
+}
+
+//////////////////////////////////////////////////
+
+func (s *scheduler) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return len(s.items)
+}
+
+func (s *scheduler) Set(handle Handle, due time.Time, priority int) {
+	if handle == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.index == nil {
+		s.index = make(map[Handle]*scheduleItem)
+	}
+
+	if item, ok := s.index[handle]; ok {
+		item.due = due
+		item.priority = priority
+		heap.Fix(&s.items, item.position)
+
+		return
+	}
+
+	item := &scheduleItem{
+		handle:   handle,
+		due:      due,
+		priority: priority,
+	}
+	heap.Push(&s.items, item)
+	s.index[handle] = item
+}
+
+func (s *scheduler) Remove(handle Handle) {
+	if handle == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	item, ok := s.index[handle]
+	if !ok {
+		return
+	}
+
+	heap.Remove(&s.items, item.position)
+	delete(s.index, handle)
+}
+
+func (s *scheduler) Next() (due time.Time, ok bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.items) == 0 {
+		return
+	}
+
+	return s.items[0].due, true
+}
+
+func (s *scheduler) Due(now time.Time) (handles []Handle) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var due []*scheduleItem
+
+	// NOTE: children of an item that is not due yet cannot be due either,
+	// so only the "due" part of the heap gets visited.
+	stack := []int{0}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		if i >= len(s.items) || s.items[i].due.After(now) {
+			continue
+		}
+
+		due = append(due, s.items[i])
+		stack = append(stack, 2*i+1, 2*i+2)
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if due[i].priority != due[j].priority {
+			return due[i].priority > due[j].priority
+		}
+
+		return due[i].due.Before(due[j].due)
+	})
+
+	handles = make([]Handle, 0, len(due))
+	for _, item := range due {
+		handles = append(handles, item.handle)
+	}
+
+	return
+}
+
+func (s *scheduler) Wake(ctx context.Context, wake func(ctx context.Context)) {
+	next, ok := s.Next()
+
+	s.wakeLock.Lock()
+	defer s.wakeLock.Unlock()
+
+	if s.wakeTimer != nil {
+		s.wakeTimer.Stop()
+		s.wakeTimer = nil
+	}
+
+	if !ok || wake == nil {
+		return
+	}
+
+	in := time.Until(next)
+	if in < MinimumWakeDelay {
+		in = MinimumWakeDelay
+	}
+
+	s.wakeTimer = time.AfterFunc(in, func() {
+		wakeCtx, cancel := context.WithTimeout(ctx, MinimumWakeDelay)
+		defer cancel()
+
+		wake(wakeCtx)
+	})
+}
+
+func (s *scheduler) Sleep() {
+	s.wakeLock.Lock()
+	defer s.wakeLock.Unlock()
+
+	if s.wakeTimer != nil {
+		s.wakeTimer.Stop()
+		s.wakeTimer = nil
+	}
+}
+
+//////////////////////////////////////////////////
+
+type scheduleHeap []*scheduleItem
+
+func (h scheduleHeap) Len() int { return len(h) }
+
+func (h scheduleHeap) Less(i, j int) bool { return h[i].due.Before(h[j].due) }
+
+func (h scheduleHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].position = i
+	h[j].position = j
+}
+
+func (h *scheduleHeap) Push(x any) {
+	item := x.(*scheduleItem)
+	item.position = len(*h)
+
+	*h = append(*h, item)
+}
+
+func (h *scheduleHeap) Pop() any {
+	old := *h
+	n := len(old)
+
+	item := old[n-1]
+	old[n-1] = nil
+	item.position = -1
+
+	*h = old[:n-1]
+	return item
+}
+
+//////////////////////////////////////////////////
+
+func (cr *Crawler) reschedule(entity Entity) {
+	due := entity.due()
+	if entity.Suspended && due.IsZero() {
+		cr.schedule.Remove(entity.Handle)
+		return
+	}
+
+	cr.schedule.Set(entity.Handle, due, entity.Priority)
+}
+
+func (cr *Crawler) wake(sess *csync.Session[*Result]) {
+	cr.schedule.Wake(context.Background(), func(ctx context.Context) {
+		sess.Wake(ctx, time.Now())
+	})
+}
@@ -0,0 +1,72 @@
+package crawly
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rubpy/crawly/csync"
+)
+
+//////////////////////////////////////////////////
+
+func countPasses(listener csync.Listener[*Result], d time.Duration) (passes int) {
+	timeout := time.After(d)
+	for {
+		select {
+		case <-listener.Channel():
+			passes++
+		case <-timeout:
+			return
+		}
+	}
+}
+
+func TestCrawlerWakeRespectsSchedule(t *testing.T) {
+	cr := newTestCrawler(t)
+	SetCrawlerSettings(cr, CrawlerSettings{MinimumTrackingDelay: 20 * time.Millisecond})
+
+	a := testHandle("a")
+	cr.entities.Store(a, Entity{Handle: a})
+	cr.reschedule(Entity{Handle: a})
+
+	schedule, err := csync.ParseCron("0 0 3 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener := cr.Listen()
+	defer listener.Discard()
+
+	startTestCrawler(t, cr, SessionSettings{Schedule: schedule})
+
+	if _, err := cr.Immediate(context.Background(), 0); err != nil {
+		t.Fatalf("Immediate: %v", err)
+	}
+
+	// NOTE: the entity becomes due again every 20ms, but the schedule does
+	// not allow another pass any time soon.
+	if passes := countPasses(listener, 300*time.Millisecond); passes != 1 {
+		t.Fatalf("expected exactly 1 pass, got %d", passes)
+	}
+}
+
+func TestCrawlerWakeWithoutSchedule(t *testing.T) {
+	cr := newTestCrawler(t)
+	SetCrawlerSettings(cr, CrawlerSettings{MinimumTrackingDelay: 20 * time.Millisecond})
+
+	a := testHandle("a")
+	cr.entities.Store(a, Entity{Handle: a})
+	cr.reschedule(Entity{Handle: a})
+
+	listener := cr.Listen()
+	defer listener.Discard()
+
+	startTestCrawler(t, cr, SessionSettings{Interval: time.Hour})
+
+	// NOTE: entities that become due before the next regular pass wake the
+	// session up.
+	if passes := countPasses(listener, 300*time.Millisecond); passes < 3 {
+		t.Fatalf("expected the entity to wake the session up, got %d passes", passes)
+	}
+}
@@ -12,10 +12,16 @@ type CrawlerSettings struct {
 	TrackingOrderTimeout         time.Duration `json:"tracking_order_timeout"`
 	MinimumTrackingOrderDelay    time.Duration `json:"minimum_tracking_order_delay"`
 	MaximumTrackingOrderAttempts int           `json:"maximum_tracking_order_attempts"`
+	TrackingOrderBackoff         BackoffPolicy `json:"-"`
 
 	TrackingTimeout         time.Duration `json:"tracking_timeout"`
 	MinimumTrackingDelay    time.Duration `json:"minimum_tracking_delay"`
 	MaximumTrackingAttempts int           `json:"maximum_tracking_attempts"`
+	TrackingBackoff         BackoffPolicy `json:"-"`
+	MaximumTrackingPanics   int           `json:"maximum_tracking_panics"`
+
+	OrderConcurrency  int `json:"order_concurrency"`
+	EntityConcurrency int `json:"entity_concurrency"`
 }
 
 var DefaultCrawlerSettings = CrawlerSettings{
@@ -26,10 +32,62 @@ var DefaultCrawlerSettings = CrawlerSettings{
 	TrackingTimeout:         45 * time.Second,
 	MinimumTrackingDelay:    10 * time.Second,
 	MaximumTrackingAttempts: 10,
+	MaximumTrackingPanics:   3,
+
+	OrderConcurrency:  1,
+	EntityConcurrency: 4,
 }
 
 type SessionSettings csync.SessionSettings
 
+type SettingsOverride struct {
+	TrackingOrderTimeout         time.Duration `json:"tracking_order_timeout,omitempty"`
+	MinimumTrackingOrderDelay    time.Duration `json:"minimum_tracking_order_delay,omitempty"`
+	MaximumTrackingOrderAttempts int           `json:"maximum_tracking_order_attempts,omitempty"`
+	TrackingOrderBackoff         BackoffPolicy `json:"-"`
+
+	TrackingTimeout         time.Duration `json:"tracking_timeout,omitempty"`
+	MinimumTrackingDelay    time.Duration `json:"minimum_tracking_delay,omitempty"`
+	MaximumTrackingAttempts int           `json:"maximum_tracking_attempts,omitempty"`
+	TrackingBackoff         BackoffPolicy `json:"-"`
+	MaximumTrackingPanics   int           `json:"maximum_tracking_panics,omitempty"`
+}
+
+func (o *SettingsOverride) apply(settings *CrawlerSettings) {
+	if o == nil || settings == nil {
+		return
+	}
+
+	if o.TrackingOrderTimeout != 0 {
+		settings.TrackingOrderTimeout = o.TrackingOrderTimeout
+	}
+	if o.MinimumTrackingOrderDelay != 0 {
+		settings.MinimumTrackingOrderDelay = o.MinimumTrackingOrderDelay
+	}
+	if o.MaximumTrackingOrderAttempts != 0 {
+		settings.MaximumTrackingOrderAttempts = o.MaximumTrackingOrderAttempts
+	}
+	if o.TrackingOrderBackoff != nil {
+		settings.TrackingOrderBackoff = o.TrackingOrderBackoff
+	}
+
+	if o.TrackingTimeout != 0 {
+		settings.TrackingTimeout = o.TrackingTimeout
+	}
+	if o.MinimumTrackingDelay != 0 {
+		settings.MinimumTrackingDelay = o.MinimumTrackingDelay
+	}
+	if o.MaximumTrackingAttempts != 0 {
+		settings.MaximumTrackingAttempts = o.MaximumTrackingAttempts
+	}
+	if o.TrackingBackoff != nil {
+		settings.TrackingBackoff = o.TrackingBackoff
+	}
+	if o.MaximumTrackingPanics != 0 {
+		settings.MaximumTrackingPanics = o.MaximumTrackingPanics
+	}
+}
+
 //////////////////////////////////////////////////
 
 func (cr *Crawler) loadSettings() CrawlerSettings {
@@ -55,3 +113,38 @@ func SetCrawlerSettings(cr *Crawler, settings CrawlerSettings) {
 
 	cr.setSettings(settings)
 }
+
+func LoadCrawlerKindSettings(cr *Crawler, kind string) (override SettingsOverride, ok bool) {
+	if cr == nil {
+		return
+	}
+
+	return cr.kindSettings.Load(kind)
+}
+
+func SetCrawlerKindSettings(cr *Crawler, kind string, override SettingsOverride) {
+	if cr == nil {
+		return
+	}
+
+	cr.kindSettings.Store(kind, override)
+}
+
+func DeleteCrawlerKindSettings(cr *Crawler, kind string) {
+	if cr == nil {
+		return
+	}
+
+	cr.kindSettings.Delete(kind)
+}
+
+func (cr *Crawler) resolveSettings(handle Handle, override *SettingsOverride) (settings CrawlerSettings) {
+	settings = cr.loadSettings()
+
+	if kindOverride, ok := cr.kindSettings.Load(HandleKind(handle)); ok {
+		kindOverride.apply(&settings)
+	}
+	override.apply(&settings)
+
+	return
+}
@@ -0,0 +1,211 @@
+package crawly
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/rubpy/crawly/clog"
+)
+
+//////////////////////////////////////////////////
+
+var (
+	InvalidStoreRecord = errors.New("invalid store record")
+)
+
+type Store interface {
+	Load(ctx context.Context, kind StoreRecordKind, handle Handle) (record StoreRecord, ok bool, err error)
+	Save(ctx context.Context, record StoreRecord) error
+	Delete(ctx context.Context, kind StoreRecordKind, handle Handle) error
+	Iterate(ctx context.Context, f func(record StoreRecord) bool) error
+}
+
+type StoreRecord struct {
+	Kind StoreRecordKind `json:"kind"`
+
+	Order  Order  `json:"order"`
+	Entity Entity `json:"entity"`
+}
+
+func (rec StoreRecord) Handle() Handle {
+	switch rec.Kind {
+	case StoreRecordOrder:
+		return rec.Order.Handle
+	case StoreRecordEntity:
+		return rec.Entity.Handle
+	}
+
+	return nil
+}
+
+func (rec StoreRecord) Valid() bool {
+	h := rec.Handle()
+
+	return h != nil && h.Valid()
+}
+
+//////////////////////////////////////////////////
+
+type StoreRecordKind uint
+
+const (
+	StoreRecordNone StoreRecordKind = iota
+	StoreRecordOrder
+	StoreRecordEntity
+)
+
+func (kind StoreRecordKind) String() string {
+	switch kind {
+	case StoreRecordOrder:
+		return "order"
+	case StoreRecordEntity:
+		return "entity"
+	}
+
+	return "none"
+}
+
+//////////////////////////////////////////////////
+
+type crawlerStore struct {
+	Store
+}
+
+func LoadCrawlerStore(cr *Crawler) (store Store) {
+	if cr == nil {
+		return
+	}
+
+	return cr.loadStore()
+}
+
+// NOTE: every handle kind has to be registered (see RegisterHandle) before
+// its handles can be persisted. Orders that cannot be persisted are rejected
+// by Track (and friends), whereas failures to persist committed results are
+// only logged.
+func SetCrawlerStore(cr *Crawler, store Store) {
+	if cr == nil {
+		return
+	}
+
+	cr.setStore(store)
+}
+
+func (cr *Crawler) loadStore() Store {
+	return cr.store.Load().Store
+}
+
+func (cr *Crawler) setStore(store Store) {
+	cr.store.Store(crawlerStore{store})
+	cr.restored.Store(false)
+}
+
+//////////////////////////////////////////////////
+
+func (cr *Crawler) restore(ctx context.Context) (err error) {
+	store := cr.loadStore()
+	if store == nil {
+		return
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	orders, entities := 0, 0
+	err = store.Iterate(ctx, func(rec StoreRecord) bool {
+		if !rec.Valid() {
+			return true
+		}
+
+		switch rec.Kind {
+		case StoreRecordOrder:
+			// NOTE: live orders (and their callbacks) take precedence over
+			// persisted ones.
+			if _, loaded := cr.orders.LoadOrStore(rec.Order.Handle, rec.Order); !loaded {
+				orders++
+			}
+
+			for {
+				seq := cr.orderSequence.Load()
+				if rec.Order.Sequence <= seq || cr.orderSequence.CompareAndSwap(seq, rec.Order.Sequence) {
+					break
+				}
+			}
+
+		case StoreRecordEntity:
+			if _, loaded := cr.entities.LoadOrStore(rec.Entity.Handle, rec.Entity); !loaded {
+				cr.reschedule(rec.Entity)
+				entities++
+			}
+		}
+
+		return true
+	})
+
+	lp := clog.Params{
+		Message: "store:restore",
+		Level:   slog.LevelInfo,
+		Err:     err,
+
+		Values: clog.ParamGroup{
+			"orders":   orders,
+			"entities": entities,
+		},
+	}
+	cr.Log(ctx, lp)
+
+	return
+}
+
+func (cr *Crawler) persist(ctx context.Context, kind StoreRecordKind, handle Handle, rec *StoreRecord) (err error) {
+	store := cr.loadStore()
+	if store == nil {
+		return
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	} else {
+		// NOTE: a result that has already been committed in memory
+		// should still be written through, even if the pass has ended.
+		ctx = context.WithoutCancel(ctx)
+	}
+
+	if rec != nil {
+		err = store.Save(ctx, *rec)
+	} else {
+		err = store.Delete(ctx, kind, handle)
+	}
+
+	if err != nil {
+		lp := clog.Params{
+			Message: "store:persist",
+			Level:   slog.LevelError,
+			Err:     err,
+
+			Values: clog.ParamGroup{
+				"kind":   kind,
+				"handle": handle,
+			},
+		}
+		cr.Log(ctx, lp)
+	}
+
+	return
+}
+
+func (cr *Crawler) persistOrder(ctx context.Context, order Order) error {
+	return cr.persist(ctx, StoreRecordOrder, order.Handle, &StoreRecord{
+		Kind:  StoreRecordOrder,
+		Order: order,
+	})
+}
+
+func (cr *Crawler) persistEntity(ctx context.Context, entity Entity) error {
+	return cr.persist(ctx, StoreRecordEntity, entity.Handle, &StoreRecord{
+		Kind:   StoreRecordEntity,
+		Entity: entity,
+	})
+}
@@ -0,0 +1,520 @@
+package crawly
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+//////////////////////////////////////////////////
+
+var (
+	ClosedFileStore = errors.New("file store is closed")
+)
+
+var DefaultFileStoreCompactionThreshold = 1024
+
+type FileStore struct {
+	lock   sync.Mutex
+	cfg    fileStoreConfig
+	path   string
+	file   *os.File
+	closed bool
+
+	records map[fileStoreKey]fileStoreLine
+	garbage int
+}
+
+type fileStoreKey struct {
+	kind   StoreRecordKind
+	handle string
+}
+
+type fileStoreLine struct {
+	Op     fileStoreOp     `json:"op"`
+	Kind   StoreRecordKind `json:"kind"`
+	Handle string          `json:"handle"`
+	Value  json.RawMessage `json:"value,omitempty"`
+}
+
+type fileStoreOp string
+
+const (
+	fileStoreOpSave   fileStoreOp = "save"
+	fileStoreOpDelete fileStoreOp = "delete"
+)
+
+func NewFileStore(path string, opts ...FileStoreOption) (*FileStore, error) {
+	var cfg fileStoreConfig
+
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if err := validateFileStoreConfig(&cfg); err != nil {
+		return nil, err
+	}
+
+	fs := &FileStore{
+		cfg:  cfg,
+		path: path,
+	}
+	if err := fs.open(); err != nil {
+		return nil, err
+	}
+
+	return fs, nil
+}
+
+//////////////////////////////////////////////////
+
+func (fs *FileStore) Path() string {
+	return fs.path
+}
+
+func (fs *FileStore) Load(ctx context.Context, kind StoreRecordKind, handle Handle) (record StoreRecord, ok bool, err error) {
+	if ctx != nil {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+	}
+
+	if handle == nil {
+		err = InvalidHandle
+		return
+	}
+
+	text, err := fs.cfg.marshalHandle(handle)
+	if err != nil {
+		return
+	}
+
+	fs.lock.Lock()
+	line, ok := fs.records[fileStoreKey{kind, text}]
+	fs.lock.Unlock()
+
+	if !ok {
+		return
+	}
+
+	record, err = fs.decode(line)
+	if err != nil {
+		ok = false
+	}
+
+	return
+}
+
+func (fs *FileStore) Save(ctx context.Context, record StoreRecord) (err error) {
+	if ctx != nil {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+	}
+
+	line, err := fs.encode(record)
+	if err != nil {
+		return
+	}
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if err = fs.append(line); err != nil {
+		return
+	}
+
+	key := fileStoreKey{line.Kind, line.Handle}
+	if _, ok := fs.records[key]; ok {
+		fs.garbage++
+	}
+	fs.records[key] = line
+
+	return fs.maybeCompact()
+}
+
+func (fs *FileStore) Delete(ctx context.Context, kind StoreRecordKind, handle Handle) (err error) {
+	if ctx != nil {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+	}
+
+	if handle == nil {
+		return InvalidHandle
+	}
+
+	text, err := fs.cfg.marshalHandle(handle)
+	if err != nil {
+		return
+	}
+
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	key := fileStoreKey{kind, text}
+	if _, ok := fs.records[key]; !ok {
+		return
+	}
+
+	if err = fs.append(fileStoreLine{
+		Op:     fileStoreOpDelete,
+		Kind:   kind,
+		Handle: text,
+	}); err != nil {
+		return
+	}
+
+	delete(fs.records, key)
+	fs.garbage += 2
+
+	return fs.maybeCompact()
+}
+
+func (fs *FileStore) Iterate(ctx context.Context, f func(record StoreRecord) bool) (err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	fs.lock.Lock()
+	lines := make([]fileStoreLine, 0, len(fs.records))
+	for _, line := range fs.records {
+		lines = append(lines, line)
+	}
+	fs.lock.Unlock()
+
+	for _, line := range lines {
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		var rec StoreRecord
+		if rec, err = fs.decode(line); err != nil {
+			return
+		}
+
+		if !f(rec) {
+			break
+		}
+	}
+
+	return
+}
+
+func (fs *FileStore) Compact() error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	return fs.compact()
+}
+
+func (fs *FileStore) Close() error {
+	fs.lock.Lock()
+	defer fs.lock.Unlock()
+
+	if fs.closed {
+		return nil
+	}
+	fs.closed = true
+
+	if err := fs.file.Sync(); err != nil {
+		fs.file.Close()
+		return err
+	}
+
+	return fs.file.Close()
+}
+
+//////////////////////////////////////////////////
+
+func (fs *FileStore) open() (err error) {
+	fs.file, err = os.OpenFile(fs.path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return
+	}
+
+	fs.records = make(map[fileStoreKey]fileStoreLine)
+	fs.garbage = 0
+
+	var offset int64
+	r := bufio.NewReader(fs.file)
+	for {
+		b, readErr := r.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			fs.file.Close()
+			return readErr
+		}
+
+		complete := len(b) > 0 && b[len(b)-1] == '\n'
+		if data := bytes.TrimSpace(b); len(data) > 0 {
+			var line fileStoreLine
+			if decodeErr := json.Unmarshal(data, &line); decodeErr != nil {
+				if complete {
+					fs.file.Close()
+					return fmt.Errorf("crawly.FileStore: corrupted record at offset %d: %w", offset, decodeErr)
+				}
+
+				// NOTE: a torn write at the end of the log (e.g., after a
+				// crash) is dropped.
+				if err = fs.file.Truncate(offset); err != nil {
+					fs.file.Close()
+					return
+				}
+				break
+			}
+
+			fs.replay(line)
+		}
+
+		if !complete {
+			if len(bytes.TrimSpace(b)) > 0 {
+				if _, err = fs.file.WriteAt([]byte{'\n'}, offset+int64(len(b))); err != nil {
+					fs.file.Close()
+					return
+				}
+			}
+			break
+		}
+
+		offset += int64(len(b))
+	}
+
+	if _, err = fs.file.Seek(0, io.SeekEnd); err != nil {
+		fs.file.Close()
+		return
+	}
+
+	return fs.maybeCompact()
+}
+
+func (fs *FileStore) replay(line fileStoreLine) {
+	key := fileStoreKey{line.Kind, line.Handle}
+
+	switch line.Op {
+	case fileStoreOpSave:
+		if _, ok := fs.records[key]; ok {
+			fs.garbage++
+		}
+		fs.records[key] = line
+
+	case fileStoreOpDelete:
+		if _, ok := fs.records[key]; ok {
+			fs.garbage++
+		}
+		delete(fs.records, key)
+		fs.garbage++
+	}
+}
+
+func (fs *FileStore) append(line fileStoreLine) error {
+	if fs.closed {
+		return ClosedFileStore
+	}
+
+	b, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	_, err = fs.file.Write(append(b, '\n'))
+	return err
+}
+
+func (fs *FileStore) maybeCompact() error {
+	threshold := fs.cfg.compactionThreshold
+	if threshold < 1 || fs.garbage < threshold || fs.garbage < len(fs.records) {
+		return nil
+	}
+
+	return fs.compact()
+}
+
+func (fs *FileStore) compact() (err error) {
+	if fs.closed {
+		return ClosedFileStore
+	}
+
+	tmpPath := fs.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return
+	}
+
+	w := bufio.NewWriter(tmp)
+	for _, line := range fs.records {
+		var b []byte
+		if b, err = json.Marshal(line); err != nil {
+			break
+		}
+
+		if _, err = w.Write(append(b, '\n')); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return
+	}
+
+	if err = os.Rename(tmpPath, fs.path); err != nil {
+		os.Remove(tmpPath)
+		return
+	}
+
+	fs.file.Close()
+	if fs.file, err = os.OpenFile(fs.path, os.O_RDWR|os.O_APPEND, 0o644); err != nil {
+		fs.closed = true
+		return
+	}
+
+	fs.garbage = 0
+	return
+}
+
+func (fs *FileStore) encode(record StoreRecord) (line fileStoreLine, err error) {
+	if !record.Valid() {
+		err = InvalidStoreRecord
+		return
+	}
+
+	line.Op = fileStoreOpSave
+	line.Kind = record.Kind
+
+	if line.Handle, err = fs.cfg.marshalHandle(record.Handle()); err != nil {
+		return
+	}
+
+	// NOTE: the handle is stored separately (in its textual form).
+	switch record.Kind {
+	case StoreRecordOrder:
+		order := record.Order
+		order.Handle = nil
+
+		line.Value, err = json.Marshal(order)
+
+	case StoreRecordEntity:
+		entity := record.Entity
+		entity.Handle = nil
+
+		line.Value, err = json.Marshal(entity)
+
+	default:
+		err = InvalidStoreRecord
+	}
+
+	return
+}
+
+func (fs *FileStore) decode(line fileStoreLine) (record StoreRecord, err error) {
+	handle, err := fs.cfg.unmarshalHandle(line.Handle)
+	if err != nil {
+		return
+	}
+
+	record.Kind = line.Kind
+
+	var data any
+	switch line.Kind {
+	case StoreRecordOrder:
+		if err = json.Unmarshal(line.Value, &record.Order); err != nil {
+			return
+		}
+		record.Order.Handle = handle
+		data = record.Order.Data
+
+	case StoreRecordEntity:
+		if err = json.Unmarshal(line.Value, &record.Entity); err != nil {
+			return
+		}
+		record.Entity.Handle = handle
+		data = record.Entity.Data
+
+	default:
+		err = InvalidStoreRecord
+		return
+	}
+
+	if fs.cfg.decodeData != nil {
+		var raw struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err = json.Unmarshal(line.Value, &raw); err != nil {
+			return
+		}
+
+		if data, err = fs.cfg.decodeData(handle, raw.Data); err != nil {
+			return
+		}
+	}
+
+	switch line.Kind {
+	case StoreRecordOrder:
+		record.Order.Data = data
+	case StoreRecordEntity:
+		record.Entity.Data = data
+	}
+
+	return
+}
+
+//////////////////////////////////////////////////
+
+type fileStoreConfig struct {
+	marshalHandle       func(handle Handle) (string, error)
+	unmarshalHandle     func(text string) (Handle, error)
+	decodeData          func(handle Handle, data json.RawMessage) (any, error)
+	compactionThreshold int
+}
+
+func validateFileStoreConfig(cfg *fileStoreConfig) error {
+	if cfg.marshalHandle == nil {
+		cfg.marshalHandle = MarshalHandle
+	}
+	if cfg.unmarshalHandle == nil {
+		cfg.unmarshalHandle = UnmarshalHandle
+	}
+
+	if cfg.compactionThreshold == 0 {
+		cfg.compactionThreshold = DefaultFileStoreCompactionThreshold
+	}
+
+	return nil
+}
+
+type FileStoreOption func(cfg *fileStoreConfig)
+
+//////////////////////////////////////////////////
+
+func WithStoreHandleCodec(marshal func(handle Handle) (string, error), unmarshal func(text string) (Handle, error)) FileStoreOption {
+	return func(cfg *fileStoreConfig) {
+		cfg.marshalHandle = marshal
+		cfg.unmarshalHandle = unmarshal
+	}
+}
+
+func WithStoreDataDecoder(decode func(handle Handle, data json.RawMessage) (any, error)) FileStoreOption {
+	return func(cfg *fileStoreConfig) {
+		cfg.decodeData = decode
+	}
+}
+
+func WithStoreCompactionThreshold(threshold int) FileStoreOption {
+	return func(cfg *fileStoreConfig) {
+		cfg.compactionThreshold = threshold
+	}
+}
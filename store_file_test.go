@@ -0,0 +1,343 @@
+package crawly
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////
+
+type testHandle string
+
+func (h testHandle) Equal(handle Handle) bool {
+	other, ok := handle.(testHandle)
+	return ok && other == h
+}
+
+func (h testHandle) Valid() bool    { return h != "" }
+func (h testHandle) String() string { return string(h) }
+
+var registerTestHandleOnce sync.Once
+
+func registerTestHandle(t *testing.T) {
+	t.Helper()
+
+	registerTestHandleOnce.Do(func() {
+		err := RegisterHandle("test", func(text string) (testHandle, error) {
+			return testHandle(text), nil
+		}, nil)
+		if err != nil {
+			t.Fatalf("RegisterHandle: %v", err)
+		}
+	})
+}
+
+func openTestFileStore(t *testing.T, path string, opts ...FileStoreOption) *FileStore {
+	t.Helper()
+
+	fs, err := NewFileStore(path, opts...)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	return fs
+}
+
+func countStoreRecords(t *testing.T, fs *FileStore) (records map[string]StoreRecord) {
+	t.Helper()
+
+	records = make(map[string]StoreRecord)
+	err := fs.Iterate(context.Background(), func(rec StoreRecord) bool {
+		records[rec.Kind.String()+"/"+rec.Handle().String()] = rec
+		return true
+	})
+	if err != nil {
+		t.Fatalf("Iterate: %v", err)
+	}
+
+	return
+}
+
+//////////////////////////////////////////////////
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	registerTestHandle(t)
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.log")
+
+	fs := openTestFileStore(t, path)
+
+	next := time.Now().Add(time.Minute).Truncate(time.Second)
+	entity := Entity{
+		Handle:  testHandle("a"),
+		Attempt: 2,
+		NextRun: next,
+		Data:    "payload",
+		Tags:    []string{"x"},
+	}
+	order := Order{
+		Command:  TrackingCommandStart,
+		Sequence: 7,
+		Handle:   testHandle("b"),
+	}
+
+	for _, rec := range []StoreRecord{
+		{Kind: StoreRecordEntity, Entity: entity},
+		{Kind: StoreRecordOrder, Order: order},
+		{Kind: StoreRecordEntity, Entity: Entity{Handle: testHandle("c")}},
+	} {
+		if err := fs.Save(ctx, rec); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	if err := fs.Delete(ctx, StoreRecordEntity, testHandle("c")); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if err := fs.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	fs = openTestFileStore(t, path)
+	defer fs.Close()
+
+	records := countStoreRecords(t, fs)
+	if len(records) != 2 {
+		t.Fatalf("expected 2 records after replay, got %d", len(records))
+	}
+
+	rec, ok, err := fs.Load(ctx, StoreRecordEntity, testHandle("a"))
+	if err != nil || !ok {
+		t.Fatalf("Load: ok=%v err=%v", ok, err)
+	}
+	if rec.Entity.Attempt != 2 || !rec.Entity.NextRun.Equal(next) || rec.Entity.Data != "payload" || !rec.Entity.HasTags("x") {
+		t.Fatalf("entity did not round-trip: %+v", rec.Entity)
+	}
+	if !rec.Entity.Handle.Equal(testHandle("a")) {
+		t.Fatalf("handle did not round-trip: %v", rec.Entity.Handle)
+	}
+
+	rec, ok, err = fs.Load(ctx, StoreRecordOrder, testHandle("b"))
+	if err != nil || !ok || rec.Order.Sequence != 7 || rec.Order.Command != TrackingCommandStart {
+		t.Fatalf("order did not round-trip: ok=%v err=%v %+v", ok, err, rec.Order)
+	}
+
+	if _, ok, _ = fs.Load(ctx, StoreRecordEntity, testHandle("c")); ok {
+		t.Fatalf("deleted record was replayed")
+	}
+}
+
+func TestFileStoreTornTail(t *testing.T) {
+	registerTestHandle(t)
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.log")
+
+	fs := openTestFileStore(t, path)
+	if err := fs.Save(ctx, StoreRecord{Kind: StoreRecordEntity, Entity: Entity{Handle: testHandle("a")}}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if err := fs.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	intact, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A write that got cut off halfway through (e.g., by a crash).
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = f.WriteString(`{"op":"save","kind":2,"handle":"test:b","val`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	fs = openTestFileStore(t, path)
+
+	records := countStoreRecords(t, fs)
+	if len(records) != 1 {
+		t.Fatalf("expected only the intact record, got %d", len(records))
+	}
+
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(after) != string(intact) {
+		t.Fatalf("torn tail was not truncated:\n%q\n%q", after, intact)
+	}
+
+	// The log has to stay appendable after the truncation.
+	if err := fs.Save(ctx, StoreRecord{Kind: StoreRecordEntity, Entity: Entity{Handle: testHandle("b")}}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	fs.Close()
+
+	fs = openTestFileStore(t, path)
+	defer fs.Close()
+
+	if records = countStoreRecords(t, fs); len(records) != 2 {
+		t.Fatalf("expected 2 records, got %d", len(records))
+	}
+}
+
+func TestFileStoreCorruptedRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.log")
+
+	if err := os.WriteFile(path, []byte("{not json}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewFileStore(path); err == nil || !strings.Contains(err.Error(), "corrupted record") {
+		t.Fatalf("expected a corrupted record error, got %v", err)
+	}
+}
+
+func TestFileStoreCompaction(t *testing.T) {
+	registerTestHandle(t)
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "store.log")
+
+	fs := openTestFileStore(t, path, WithStoreCompactionThreshold(4))
+
+	for i := 0; i < 10; i++ {
+		rec := StoreRecord{Kind: StoreRecordEntity, Entity: Entity{Handle: testHandle("a"), Attempt: i}}
+		if err := fs.Save(ctx, rec); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	if err := fs.Save(ctx, StoreRecord{Kind: StoreRecordEntity, Entity: Entity{Handle: testHandle("b")}}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	if err := fs.Compact(); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(b), "\n"); lines != 2 {
+		t.Fatalf("expected 2 lines after compaction, got %d", lines)
+	}
+	if _, err := os.Stat(path + ".tmp"); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("temporary file was left behind: %v", err)
+	}
+
+	// Writes after a compaction go to the new file.
+	if err := fs.Delete(ctx, StoreRecordEntity, testHandle("b")); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	fs.Close()
+
+	fs = openTestFileStore(t, path)
+	defer fs.Close()
+
+	rec, ok, err := fs.Load(ctx, StoreRecordEntity, testHandle("a"))
+	if err != nil || !ok || rec.Entity.Attempt != 9 {
+		t.Fatalf("latest record lost in compaction: ok=%v err=%v %+v", ok, err, rec.Entity)
+	}
+	if _, ok, _ := fs.Load(ctx, StoreRecordEntity, testHandle("b")); ok {
+		t.Fatalf("deleted record survived compaction")
+	}
+}
+
+func TestTrackRejectsUnpersistableHandle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.log")
+
+	fs := openTestFileStore(t, path)
+	defer fs.Close()
+
+	cr := &Crawler{}
+	SetCrawlerStore(cr, fs)
+
+	tracked, err := cr.Track(context.Background(), unregisteredHandle("a"))
+	if !errors.Is(err, UnknownHandleKind) || tracked {
+		t.Fatalf("expected UnknownHandleKind, got tracked=%v err=%v", tracked, err)
+	}
+	if pending := cr.Pending(); len(pending) != 0 {
+		t.Fatalf("unpersistable order was queued: %v", pending)
+	}
+}
+
+type unregisteredHandle string
+
+func (h unregisteredHandle) Equal(handle Handle) bool {
+	other, ok := handle.(unregisteredHandle)
+	return ok && other == h
+}
+
+func (h unregisteredHandle) Valid() bool    { return h != "" }
+func (h unregisteredHandle) String() string { return string(h) }
+
+type iterationCountingStore struct {
+	*FileStore
+	iterations atomic.Int64
+}
+
+func (s *iterationCountingStore) Iterate(ctx context.Context, f func(record StoreRecord) bool) error {
+	s.iterations.Add(1)
+	return s.FileStore.Iterate(ctx, f)
+}
+
+func TestTrackAndWaitSurvivesRestart(t *testing.T) {
+	cr := newTestCrawler(t)
+
+	fs := openTestFileStore(t, filepath.Join(t.TempDir(), "store.log"))
+	defer fs.Close()
+
+	store := &iterationCountingStore{FileStore: fs}
+	SetCrawlerStore(cr, store)
+
+	ctx := context.Background()
+	settings := SessionSettings{Interval: 20 * time.Millisecond}
+
+	// NOTE: concurrent starts must not both restore from the store.
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cr.Start(ctx, settings)
+		}()
+	}
+	wg.Wait()
+
+	if _, err := cr.Stop(ctx); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+
+		_, err := cr.TrackAndWait(waitCtx, testHandle("a"))
+		done <- err
+	}()
+	waitFor(t, "the order to be queued", func() bool {
+		return len(cr.Pending()) == 1
+	})
+
+	startTestCrawler(t, cr, settings)
+
+	if err := <-done; err != nil {
+		t.Fatalf("TrackAndWait: %v", err)
+	}
+	if n := store.iterations.Load(); n != 1 {
+		t.Fatalf("expected the store to be restored from once, got %d", n)
+	}
+}
@@ -2,16 +2,35 @@ package crawly
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"time"
 )
 
 //////////////////////////////////////////////////
 
-func (cr *Crawler) Tracked() (handles []Handle) {
+func (cr *Crawler) Tracked(tags ...string) (handles []Handle) {
 	handles = []Handle{}
 
-	cr.entities.Range(func(handle Handle, _ Entity) bool {
-		handles = append(handles, handle)
+	cr.entities.Range(func(handle Handle, entity Entity) bool {
+		if entity.HasTags(tags...) {
+			handles = append(handles, handle)
+		}
+
+		return true
+	})
+
+	return
+}
+
+func (cr *Crawler) Suspended() (entities []Entity) {
+	entities = []Entity{}
+	now := time.Now()
+
+	cr.entities.Range(func(_ Handle, entity Entity) bool {
+		if entity.SuspendedAt(now) {
+			entities = append(entities, entity)
+		}
 
 		return true
 	})
@@ -23,73 +42,381 @@ func (cr *Crawler) IsTracked(handle Handle) bool {
 	return cr.entities.Has(handle)
 }
 
-func (cr *Crawler) Track(ctx context.Context, handle Handle) (tracked bool, err error) {
-	return cr.order(ctx, handle, TrackingCommandStart, false)
+func (cr *Crawler) Track(ctx context.Context, handle Handle, opts ...TrackOption) (tracked bool, err error) {
+	return cr.order(ctx, handle, TrackingCommandStart, false, opts...)
+}
+
+func (cr *Crawler) TrackAndWait(ctx context.Context, handle Handle, opts ...TrackOption) (result TrackingResult, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	done := make(chan TrackingResult, 1)
+	opts = append(append([]TrackOption(nil), opts...), func(order *Order) {
+		callback := order.Callback
+
+		order.Callback = func(tr TrackingResult) {
+			if callback != nil {
+				callback(tr)
+			}
+
+			select {
+			case done <- tr:
+			default:
+			}
+		}
+	})
+
+	tracked, err := cr.order(ctx, handle, TrackingCommandStart, false, opts...)
+	if err != nil {
+		return
+	}
+
+	if tracked {
+		entity, ok := cr.entities.Load(handle)
+		if ok {
+			result.Entity.Value = entity
+			return
+		}
+	}
+
+	select {
+	case result = <-done:
+		err = result.Order.Err
+
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	return
 }
 
 func (cr *Crawler) Untrack(ctx context.Context, handle Handle) (tracked bool, err error) {
 	return cr.order(ctx, handle, TrackingCommandStop, false)
 }
 
+func (cr *Crawler) ScheduleRefresh(ctx context.Context, handle Handle) (tracked bool, err error) {
+	return cr.order(ctx, handle, TrackingCommandRefresh, false)
+}
+
+func (cr *Crawler) Suspend(ctx context.Context, handle Handle) (tracked bool, err error) {
+	return cr.SuspendUntil(ctx, handle, time.Time{})
+}
+
+func (cr *Crawler) SuspendUntil(ctx context.Context, handle Handle, until time.Time) (tracked bool, err error) {
+	return cr.order(ctx, handle, TrackingCommandSuspend, false, func(order *Order) {
+		order.SuspendedUntil = until
+	})
+}
+
+func (cr *Crawler) Unsuspend(ctx context.Context, handle Handle) (tracked bool, err error) {
+	return cr.order(ctx, handle, TrackingCommandUnsuspend, false)
+}
+
+func (cr *Crawler) Update(ctx context.Context, handle Handle, data any) (tracked bool, err error) {
+	return cr.order(ctx, handle, TrackingCommandUpdate, false, WithData(data))
+}
+
 func (cr *Crawler) UntrackAll(ctx context.Context) (untracked int, err error) {
-	cr.entities.Range(func(handle Handle, _ Entity) bool {
-		cr.order(ctx, handle, TrackingCommandStop, false)
+	outcomes, err := cr.UntrackWhere(ctx, nil)
+	if err != nil {
+		return
+	}
+
+	var errs []error
+	for _, outcome := range outcomes {
+		if outcome.Err != nil {
+			errs = append(errs, outcome.Err)
+			continue
+		}
+
+		if outcome.Queued {
+			untracked++
+		}
+	}
+
+	err = errors.Join(errs...)
+	return
+}
+
+func (cr *Crawler) TrackMany(ctx context.Context, handles []Handle, opts ...TrackOption) (outcomes []OrderOutcome, err error) {
+	return cr.orderMany(ctx, handles, TrackingCommandStart, false, opts...)
+}
+
+func (cr *Crawler) UntrackMany(ctx context.Context, handles []Handle) (outcomes []OrderOutcome, err error) {
+	return cr.orderMany(ctx, handles, TrackingCommandStop, false)
+}
+
+func (cr *Crawler) UntrackWhere(ctx context.Context, predicate func(entity Entity) bool) (outcomes []OrderOutcome, err error) {
+	handles := []Handle{}
+
+	cr.entities.Range(func(handle Handle, entity Entity) bool {
+		if predicate == nil || predicate(entity) {
+			handles = append(handles, handle)
+		}
+
+		return true
+	})
+
+	return cr.orderMany(ctx, handles, TrackingCommandStop, false)
+}
+
+func (cr *Crawler) Pending() (orders []Order) {
+	orders = []Order{}
+
+	cr.orderLock.Lock()
+	defer cr.orderLock.Unlock()
+
+	cr.orders.Range(func(_ Handle, order Order) bool {
+		orders = append(orders, order)
 
-		untracked++
 		return true
 	})
 
 	return
 }
 
-func (cr *Crawler) order(ctx context.Context, handle Handle, command TrackingCommand, quiet bool) (tracked bool, err error) {
-	if ctx == nil {
-		ctx = context.Background()
+//////////////////////////////////////////////////
+
+type OrderOutcome struct {
+	Handle  Handle `json:"handle"`
+	Tracked bool   `json:"tracked"`
+	Queued  bool   `json:"queued"`
+	Err     error  `json:"err"`
+}
+
+func (outcome OrderOutcome) MarshalJSON() ([]byte, error) {
+	h, err := marshalHandleJSON(outcome.Handle)
+	if err != nil {
+		return nil, err
 	}
 
-	if !handle.Valid() {
-		err = InvalidHandle
+	return json.Marshal(struct {
+		Handle  json.RawMessage `json:"handle"`
+		Tracked bool            `json:"tracked"`
+		Queued  bool            `json:"queued"`
+		Err     *string         `json:"err"`
+	}{h, outcome.Tracked, outcome.Queued, marshalError(outcome.Err)})
+}
+
+func (cr *Crawler) order(ctx context.Context, handle Handle, command TrackingCommand, quiet bool, opts ...TrackOption) (tracked bool, err error) {
+	outcomes, err := cr.orderMany(ctx, []Handle{handle}, command, quiet, opts...)
+	if err != nil {
 		return
 	}
 
-	if cr.IsTracked(handle) {
-		tracked = true
+	return outcomes[0].Tracked, outcomes[0].Err
+}
+
+func (cr *Crawler) orderMany(ctx context.Context, handles []Handle, command TrackingCommand, quiet bool, opts ...TrackOption) (outcomes []OrderOutcome, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	} else if err = ctx.Err(); err != nil {
+		return
 	}
 
 	switch command {
-	case TrackingCommandStart:
-		if tracked {
-			return
-		}
-
-	case TrackingCommandStop:
-		if !tracked {
-			return
-		}
-
+	case TrackingCommandStart, TrackingCommandStop:
+	case TrackingCommandRefresh, TrackingCommandSuspend, TrackingCommandUnsuspend, TrackingCommandUpdate:
 	default:
 		err = InvalidTrackingCommand
 		return
 	}
 
-	cr.orders.Store(handle, Order{
-		Command: command,
-		Handle:  handle,
-	})
+	outcomes = make([]OrderOutcome, len(handles))
+
+	// NOTE: all orders are enqueued at once, so that a concurrent pass
+	// picks up either all or none of them.
+	var superseded []Order
+	queued := 0
+
+	cr.orderLock.Lock()
+	for i, handle := range handles {
+		outcome := &outcomes[i]
+		outcome.Handle = handle
+
+		if handle == nil || !handle.Valid() {
+			outcome.Err = InvalidHandle
+			continue
+		}
+
+		outcome.Tracked = cr.IsTracked(handle)
+		pending, hasPending := cr.orders.Load(handle)
+
+		order := Order{
+			Command: command,
+			Handle:  handle,
+		}
+		for _, opt := range opts {
+			opt(&order)
+		}
+		merged := false
+
+		switch command {
+		case TrackingCommandStart:
+			// NOTE: a duplicate start order is skipped, so that the pending
+			// one keeps its attempts (and its backoff); its callback gets
+			// called along with the pending one's, though.
+			if hasPending && pending.Command == TrackingCommandStart {
+				if order.Callback != nil {
+					pending.Callback = chainOrderCallbacks(pending.Callback, order.Callback)
+					cr.orders.Store(handle, pending)
+				}
+
+				continue
+			}
+
+			if outcome.Tracked {
+				// NOTE: tracking a handle that is about to be untracked
+				// merely cancels the pending stop order.
+				if hasPending && pending.Command == TrackingCommandStop {
+					if outcome.Err = cr.cancelOrder(ctx, pending); outcome.Err == nil {
+						superseded = append(superseded, pending)
+					}
+				}
+
+				continue
+			}
+
+		case TrackingCommandStop:
+			// NOTE: untracking a handle that is yet to be tracked merely
+			// cancels the pending start order.
+			if hasPending && pending.Command == TrackingCommandStart {
+				if outcome.Err = cr.cancelOrder(ctx, pending); outcome.Err == nil {
+					superseded = append(superseded, pending)
+				}
+
+				continue
+			}
+
+			if !outcome.Tracked || (hasPending && pending.Command == TrackingCommandStop) {
+				continue
+			}
+
+		default:
+			if !outcome.Tracked {
+				continue
+			}
+
+			switch {
+			case !hasPending:
+			case pending.Command == TrackingCommandStop:
+				// NOTE: a pending stop order must not be superseded by an
+				// order that merely modifies the entity.
+				continue
+			case pending.Command != TrackingCommandStart:
+				order = pending.merge(order)
+				merged = true
+			}
+		}
 
-	if !quiet && cr.session.PauseIdle() {
-		if cr.session.Paused() {
-			cr.session.Resume(ctx)
+		// NOTE: an order that cannot be persisted (e.g., because its handle
+		// kind has not been registered) is rejected up front.
+		order.Sequence = cr.orderSequence.Add(1)
+		if outcome.Err = cr.persistOrder(ctx, order); outcome.Err != nil {
+			continue
 		}
+
+		if previous, ok := cr.orders.Swap(handle, order); ok && !merged {
+			superseded = append(superseded, previous)
+		}
+
+		outcome.Queued = true
+		queued++
+	}
+	cr.orderLock.Unlock()
+
+	for _, previous := range superseded {
+		if previous.Callback == nil {
+			continue
+		}
+
+		cr.callOrderCallback(ctx, previous.Callback, TrackingResult{
+			Order: actionableResult[Order]{
+				Action: TrackingActionRemove,
+				Value:  previous,
+				Err:    SupersededOrder,
+			},
+		})
+	}
+
+	if queued > 0 && !quiet && cr.session.PauseIdle() {
+		// NOTE: resuming is requested even if the session is not paused
+		// (yet), so that a pass which is still in progress does not put
+		// the session to sleep right after this order has been stored.
+		cr.session.Resume(ctx)
 	}
 
 	return
 }
 
+// NOTE: has to be called with orderLock held.
+func (cr *Crawler) cancelOrder(ctx context.Context, order Order) (err error) {
+	if err = cr.persist(ctx, StoreRecordOrder, order.Handle, nil); err != nil {
+		return
+	}
+
+	cr.orders.Delete(order.Handle)
+	return
+}
+
+//////////////////////////////////////////////////
+
+type TrackOption func(order *Order)
+
+func WithSettings(override SettingsOverride) TrackOption {
+	return func(order *Order) {
+		order.Settings = &override
+	}
+}
+
+func WithData(data any) TrackOption {
+	return func(order *Order) {
+		order.Data = data
+	}
+}
+
+func WithTags(tags ...string) TrackOption {
+	return func(order *Order) {
+		order.Tags = append(order.Tags, tags...)
+	}
+}
+
+func WithPriority(priority int) TrackOption {
+	return func(order *Order) {
+		order.Priority = priority
+	}
+}
+
+func WithTTL(ttl time.Duration) TrackOption {
+	return func(order *Order) {
+		order.TTL = ttl
+	}
+}
+
+func WithCallback(callback OrderCallback) TrackOption {
+	return func(order *Order) {
+		order.Callback = callback
+	}
+}
+
+func chainOrderCallbacks(first OrderCallback, second OrderCallback) OrderCallback {
+	if first == nil {
+		return second
+	}
+
+	return func(result TrackingResult) {
+		first(result)
+		second(result)
+	}
+}
+
 //////////////////////////////////////////////////
 
 var (
 	InvalidTrackingCommand = errors.New("invalid tracking command")
+	SupersededOrder        = errors.New("order was superseded")
+	UntrackedHandle        = errors.New("handle is not tracked")
 
 	ExceededTrackingOrderTimeout = errors.New("exceeded tracking order timeout")
 	ExceededTrackingTimeout      = errors.New("exceeded tracking timeout")
@@ -98,37 +425,184 @@ var (
 type TrackingResult struct {
 	Order  actionableResult[Order]  `json:"order"`
 	Entity actionableResult[Entity] `json:"entity"`
+	Change *DataChange              `json:"change,omitempty"`
 }
 
 type actionableResult[T any] struct {
-	Action TrackingAction
+	Action TrackingAction `json:"action"`
+	Reason RemovalReason  `json:"reason"`
+
+	Value T     `json:"value"`
+	Err   error `json:"err"`
+}
+
+func (r actionableResult[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Action TrackingAction `json:"action"`
+		Reason RemovalReason  `json:"reason"`
 
-	Value T
-	Err   error
+		Value T       `json:"value"`
+		Err   *string `json:"err"`
+	}{r.Action, r.Reason, r.Value, marshalError(r.Err)})
 }
 
-func (cr *Crawler) commitTrackingResult(tr *TrackingResult) {
+func (r *actionableResult[T]) UnmarshalJSON(data []byte) (err error) {
+	var aux struct {
+		Action TrackingAction `json:"action"`
+		Reason RemovalReason  `json:"reason"`
+
+		Value T       `json:"value"`
+		Err   *string `json:"err"`
+	}
+	if err = json.Unmarshal(data, &aux); err != nil {
+		return
+	}
+
+	r.Action, r.Reason, r.Value, r.Err = aux.Action, aux.Reason, aux.Value, unmarshalError(aux.Err)
+	return
+}
+
+func (cr *Crawler) commitTrackingResult(ctx context.Context, tr *TrackingResult) {
 	if tr == nil {
 		return
 	}
 
+	completed, superseded := cr.commitOrder(ctx, tr)
+	if superseded {
+		// NOTE: a superseded order has no say over its entity anymore (and
+		// its callback has already been notified).
+		return
+	}
+
+	cr.commitEntity(ctx, tr)
+
+	if completed && tr.Order.Value.Callback != nil {
+		cr.callOrderCallback(ctx, tr.Order.Value.Callback, *tr)
+	}
+}
+
+func (cr *Crawler) commitOrder(ctx context.Context, tr *TrackingResult) (completed bool, superseded bool) {
 	h := tr.Order.Value.Handle
-	if h != nil && h.Valid() {
-		switch tr.Order.Action {
-		case TrackingActionRemove:
-			cr.orders.Delete(h)
-		case TrackingActionUpdate:
-			cr.orders.Store(h, tr.Order.Value)
-		}
+	if h == nil || !h.Valid() {
+		return
 	}
 
-	h = tr.Entity.Value.Handle
-	if h != nil && h.Valid() {
-		switch tr.Entity.Action {
-		case TrackingActionRemove:
-			cr.entities.Delete(h)
-		case TrackingActionUpdate:
-			cr.entities.Store(h, tr.Entity.Value)
+	cr.orderLock.Lock()
+	current, ok := cr.orders.Load(h)
+	if !ok || current.Sequence != tr.Order.Value.Sequence {
+		// NOTE: the order has been superseded (or withdrawn) while it was
+		// being processed.
+		cr.orderLock.Unlock()
+
+		superseded = true
+		return
+	}
+
+	// NOTE: callbacks might have been attached to the order while it was
+	// being processed.
+	tr.Order.Value.Callback = current.Callback
+
+	switch tr.Order.Action {
+	case TrackingActionRemove:
+		cr.orders.Delete(h)
+		cr.persist(ctx, StoreRecordOrder, h, nil)
+	case TrackingActionUpdate:
+		cr.orders.Store(h, tr.Order.Value)
+		cr.persistOrder(ctx, tr.Order.Value)
+	}
+	cr.orderLock.Unlock()
+
+	if tr.Order.Action != TrackingActionRemove {
+		return
+	}
+
+	if tr.Order.Err != nil {
+		cr.emit(Event{
+			Kind:   EventOrderRejected,
+			Reason: tr.Order.Reason,
+
+			Handle:  h,
+			Attempt: tr.Order.Value.Attempt,
+			Err:     tr.Order.Err,
+		})
+	}
+
+	completed = true
+	return
+}
+
+func (cr *Crawler) commitEntity(ctx context.Context, tr *TrackingResult) {
+	h := tr.Entity.Value.Handle
+	if h == nil || !h.Valid() {
+		return
+	}
+
+	switch tr.Entity.Action {
+	case TrackingActionRemove:
+		lock := cr.entityLock(h)
+		lock.Lock()
+		_, loaded := cr.entities.LoadAndDelete(h)
+		cr.schedule.Remove(h)
+		cr.persist(ctx, StoreRecordEntity, h, nil)
+		lock.Unlock()
+
+		if loaded {
+			cr.emit(Event{
+				Kind:   EventEntityRemoved,
+				Reason: tr.Entity.Reason,
+
+				Handle:  h,
+				Attempt: tr.Entity.Value.Attempt,
+				Err:     tr.Entity.Err,
+			})
+		}
+
+	case TrackingActionUpdate:
+		lock := cr.entityLock(h)
+		lock.Lock()
+		previous, loaded := cr.entities.Load(h)
+		if !loaded && tr.Order.Value.Command != TrackingCommandStart {
+			// NOTE: only a start order may add an entity; anything else
+			// must not bring back one that has been removed in the meantime
+			// (e.g., while it was being refreshed).
+			lock.Unlock()
+			return
+		}
+
+		if loaded && previous.ExpiresAt.After(tr.Entity.Value.ExpiresAt) {
+			// NOTE: the lease might have been extended (touched) while the
+			// entity was being processed.
+			tr.Entity.Value.ExpiresAt = previous.ExpiresAt
+		}
+
+		cr.entities.Store(h, tr.Entity.Value)
+		cr.reschedule(tr.Entity.Value)
+		cr.persistEntity(ctx, tr.Entity.Value)
+		lock.Unlock()
+
+		ev := Event{
+			Kind: EventEntityUpdated,
+
+			Handle:  h,
+			Attempt: tr.Entity.Value.Attempt,
+			Err:     tr.Entity.Err,
+		}
+		if !loaded {
+			ev.Kind = EventEntityAdded
+		} else if tr.Entity.Err != nil {
+			ev.Kind = EventEntityFailed
+		}
+
+		cr.emit(ev)
+
+		if tr.Change != nil {
+			cr.emit(Event{
+				Kind: EventDataChanged,
+
+				Handle:  h,
+				Attempt: tr.Entity.Value.Attempt,
+				Change:  tr.Change,
+			})
 		}
 	}
 }
@@ -141,6 +615,10 @@ const (
 	TrackingCommandNone TrackingCommand = iota
 	TrackingCommandStart
 	TrackingCommandStop
+	TrackingCommandRefresh
+	TrackingCommandSuspend
+	TrackingCommandUnsuspend
+	TrackingCommandUpdate
 )
 
 func (cmd TrackingCommand) String() string {
@@ -149,6 +627,14 @@ func (cmd TrackingCommand) String() string {
 		return "start"
 	case TrackingCommandStop:
 		return "stop"
+	case TrackingCommandRefresh:
+		return "refresh"
+	case TrackingCommandSuspend:
+		return "suspend"
+	case TrackingCommandUnsuspend:
+		return "unsuspend"
+	case TrackingCommandUpdate:
+		return "update"
 	}
 
 	return "none"
@@ -0,0 +1,319 @@
+package crawly
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+//////////////////////////////////////////////////
+
+type blockingStore struct {
+	lock    sync.Mutex
+	records map[string]StoreRecord
+
+	block   Handle
+	blocked chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingStore) Load(ctx context.Context, kind StoreRecordKind, handle Handle) (record StoreRecord, ok bool, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	record, ok = s.records[kind.String()+"/"+handle.String()]
+	return
+}
+
+func (s *blockingStore) Save(ctx context.Context, record StoreRecord) error {
+	if s.block != nil && record.Kind == StoreRecordEntity && record.Handle().Equal(s.block) {
+		close(s.blocked)
+		<-s.release
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.records == nil {
+		s.records = make(map[string]StoreRecord)
+	}
+	s.records[record.Kind.String()+"/"+record.Handle().String()] = record
+
+	return nil
+}
+
+func (s *blockingStore) Delete(ctx context.Context, kind StoreRecordKind, handle Handle) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.records, kind.String()+"/"+handle.String())
+	return nil
+}
+
+func (s *blockingStore) Iterate(ctx context.Context, f func(record StoreRecord) bool) error {
+	return nil
+}
+
+//////////////////////////////////////////////////
+
+func TestCommitEntityDoesNotBlockOtherHandles(t *testing.T) {
+	cr := &Crawler{}
+
+	a := testHandle("a")
+	b := testHandle("b")
+	for i := 0; cr.entityLock(a) == cr.entityLock(b); i++ {
+		b = testHandle("b" + string(rune('a'+i)))
+	}
+
+	store := &blockingStore{
+		block:   a,
+		blocked: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+	SetCrawlerStore(cr, store)
+	defer close(store.release)
+
+	commit := func(handle Handle) {
+		cr.commitEntity(context.Background(), &TrackingResult{
+			Order: actionableResult[Order]{
+				Value: Order{Command: TrackingCommandStart},
+			},
+			Entity: actionableResult[Entity]{
+				Action: TrackingActionUpdate,
+				Value:  Entity{Handle: handle},
+			},
+		})
+	}
+
+	go commit(a)
+	<-store.blocked
+
+	done := make(chan struct{})
+	go func() {
+		commit(b)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("commit of another handle was blocked by a pending write")
+	}
+
+	if !cr.IsTracked(b) {
+		t.Fatalf("entity was not committed")
+	}
+}
+
+func TestSupersededOrderDoesNotCommitEntity(t *testing.T) {
+	cr := newTestCrawler(t)
+	ctx := context.Background()
+
+	var superseded error
+	_, err := cr.Track(ctx, testHandle("a"), WithData("old"), WithCallback(func(result TrackingResult) {
+		superseded = result.Order.Err
+	}))
+	if err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+
+	pending := cr.Pending()
+	if len(pending) != 1 {
+		t.Fatalf("expected 1 pending order, got %d", len(pending))
+	}
+
+	var tr TrackingResult
+	if err = cr.processOrder(ctx, &pending[0], &tr); err != nil {
+		t.Fatalf("processOrder: %v", err)
+	}
+
+	// NOTE: the order gets cancelled while it is being processed.
+	if _, err = cr.Untrack(ctx, testHandle("a")); err != nil {
+		t.Fatalf("Untrack: %v", err)
+	}
+	if superseded != SupersededOrder {
+		t.Fatalf("expected the callback to get SupersededOrder, got %v", superseded)
+	}
+
+	cr.commitTrackingResult(ctx, &tr)
+
+	if cr.IsTracked(testHandle("a")) {
+		t.Fatalf("superseded order added its entity")
+	}
+	if pending = cr.Pending(); len(pending) != 0 {
+		t.Fatalf("expected no pending orders, got %+v", pending)
+	}
+}
+
+func TestUntrackCancelsPendingStart(t *testing.T) {
+	cr := newTestCrawler(t)
+	ctx := context.Background()
+	a := testHandle("a")
+
+	if _, err := cr.Track(ctx, a); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	if _, err := cr.Untrack(ctx, a); err != nil {
+		t.Fatalf("Untrack: %v", err)
+	}
+
+	if pending := cr.Pending(); len(pending) != 0 {
+		t.Fatalf("expected the start order to be cancelled, got %+v", pending)
+	}
+
+	startTestCrawler(t, cr, SessionSettings{Interval: time.Hour})
+	waitForPass(t, cr)
+
+	if cr.IsTracked(a) {
+		t.Fatalf("handle got tracked after being untracked")
+	}
+}
+
+func TestTrackCancelsPendingStop(t *testing.T) {
+	cr := newTestCrawler(t)
+	ctx := context.Background()
+	a := testHandle("a")
+
+	cr.entities.Store(a, Entity{Handle: a})
+
+	if _, err := cr.Untrack(ctx, a); err != nil {
+		t.Fatalf("Untrack: %v", err)
+	}
+	if tracked, err := cr.Track(ctx, a); err != nil || !tracked {
+		t.Fatalf("Track: tracked=%v err=%v", tracked, err)
+	}
+
+	if pending := cr.Pending(); len(pending) != 0 {
+		t.Fatalf("expected the stop order to be cancelled, got %+v", pending)
+	}
+}
+
+func TestDuplicateTrackKeepsPendingOrder(t *testing.T) {
+	cr := newTestCrawler(t)
+	ctx := context.Background()
+	a := testHandle("a")
+
+	if _, err := cr.Track(ctx, a, WithData("first")); err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+
+	// NOTE: a failed attempt, as if the order handler had returned an error.
+	pending := cr.Pending()[0]
+	pending.Attempt, pending.Failures = 2, 2
+	cr.orders.Store(a, pending)
+
+	outcomes, err := cr.TrackMany(ctx, []Handle{a}, WithData("second"))
+	if err != nil {
+		t.Fatalf("TrackMany: %v", err)
+	}
+	if outcomes[0].Queued {
+		t.Fatalf("duplicate start order was queued")
+	}
+
+	current := cr.Pending()
+	if len(current) != 1 || current[0].Sequence != pending.Sequence ||
+		current[0].Attempt != 2 || current[0].Failures != 2 || current[0].Data != "first" {
+		t.Fatalf("pending order was replaced: %+v", current)
+	}
+}
+
+func TestConcurrentTrackAndWait(t *testing.T) {
+	cr := newTestCrawler(t)
+	a := testHandle("a")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := cr.TrackAndWait(ctx, a)
+			errs <- err
+		}()
+	}
+
+	waitFor(t, "both waiters to be attached", func() bool {
+		pending := cr.Pending()
+		return len(pending) == 1 && pending[0].Callback != nil
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	startTestCrawler(t, cr, SessionSettings{})
+
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("TrackAndWait: %v", err)
+		}
+	}
+
+	if !cr.IsTracked(a) {
+		t.Fatalf("handle is not tracked")
+	}
+}
+
+func TestTrackAndWaitDoesNotModifyOptions(t *testing.T) {
+	cr := newTestCrawler(t)
+
+	opts := make([]TrackOption, 1, 2)
+	opts[0] = WithData("data")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cr.TrackAndWait(ctx, testHandle("a"), opts...)
+
+	if extra := opts[:2][1]; extra != nil {
+		t.Fatalf("TrackAndWait appended to the caller's options")
+	}
+}
+
+func TestModifyOrdersAreMerged(t *testing.T) {
+	cr := newTestCrawler(t)
+	ctx := context.Background()
+	a := testHandle("a")
+
+	cr.entities.Store(a, Entity{Handle: a})
+
+	if _, err := cr.Update(ctx, a, "data"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if _, err := cr.Suspend(ctx, a); err != nil {
+		t.Fatalf("Suspend: %v", err)
+	}
+
+	outcomes, err := cr.orderMany(ctx, []Handle{a}, TrackingCommandRefresh, false)
+	if err != nil || !outcomes[0].Queued {
+		t.Fatalf("ScheduleRefresh: %+v %v", outcomes, err)
+	}
+
+	pending := cr.Pending()
+	if len(pending) != 1 {
+		t.Fatalf("expected 1 pending order, got %d", len(pending))
+	}
+
+	var tr TrackingResult
+	if err = cr.processOrder(ctx, &pending[0], &tr); err != nil {
+		t.Fatalf("processOrder: %v", err)
+	}
+	cr.commitTrackingResult(ctx, &tr)
+
+	entity, _ := cr.entities.Load(a)
+	if entity.Data != "data" || !entity.Suspended || entity.NextRun.IsZero() {
+		t.Fatalf("merged modifications were not all applied: %+v", entity)
+	}
+	if len(cr.Pending()) != 0 {
+		t.Fatalf("merged order was not completed")
+	}
+}
+
+func TestUnsuspendResetsPanics(t *testing.T) {
+	order := Order{Command: TrackingCommandUnsuspend}
+	entity := Entity{Suspended: true, Panics: 3}
+
+	order.modifyEntity(&entity)
+
+	if entity.Suspended || entity.Panics != 0 {
+		t.Fatalf("unsuspended entity kept its state: %+v", entity)
+	}
+}